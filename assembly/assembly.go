@@ -0,0 +1,68 @@
+package assembly
+
+import (
+	"debug/pe"
+	"fmt"
+)
+
+// Model ...
+type Model struct {
+	Pth     string
+	Name    string
+	Version string
+
+	md *metadata
+}
+
+// ReferenceModel ...
+type ReferenceModel struct {
+	Name    string
+	Version string
+	Culture string
+}
+
+// Open reads the metadata of a .NET assembly (ECMA-335 PE/CLI file).
+func Open(pth string) (Model, error) {
+	file, err := pe.Open(pth)
+	if err != nil {
+		return Model{}, fmt.Errorf("failed to open assembly (%s), error: %s", pth, err)
+	}
+
+	content, err := metadataFromPE(file)
+	if closeErr := file.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Model{}, fmt.Errorf("failed to read assembly (%s) metadata, error: %s", pth, err)
+	}
+
+	md, err := parseMetadata(content)
+	if err != nil {
+		return Model{}, fmt.Errorf("failed to parse assembly (%s) metadata, error: %s", pth, err)
+	}
+
+	model := Model{Pth: pth, md: md}
+	if md.rowCount(tableAssembly) > 0 {
+		model.Name = md.string(md.cell(tableAssembly, 1, 7))
+		model.Version = version(md, tableAssembly, 1, 1)
+	}
+
+	return model, nil
+}
+
+func version(md *metadata, id int, row uint32, firstCol int) string {
+	return fmt.Sprintf("%d.%d.%d.%d", md.cell(id, row, firstCol), md.cell(id, row, firstCol+1), md.cell(id, row, firstCol+2), md.cell(id, row, firstCol+3))
+}
+
+// References returns the assemblies referenced by the assembly.
+func (model Model) References() []ReferenceModel {
+	references := []ReferenceModel{}
+	for row := uint32(1); row <= model.md.rowCount(tableAssemblyRef); row++ {
+		references = append(references, ReferenceModel{
+			Name:    model.md.string(model.md.cell(tableAssemblyRef, row, 6)),
+			Version: version(model.md, tableAssemblyRef, row, 0),
+			Culture: model.md.string(model.md.cell(tableAssemblyRef, row, 7)),
+		})
+	}
+	return references
+}
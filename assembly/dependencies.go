@@ -0,0 +1,145 @@
+package assembly
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MissingReferenceModel ...
+type MissingReferenceModel struct {
+	Reference  ReferenceModel
+	ReferredBy string
+}
+
+// DependenciesModel ...
+type DependenciesModel struct {
+	// Files are the paths of the resolved assemblies and their companion files (symbols, configs, satellite assemblies)
+	Files   []string
+	Missing []MissingReferenceModel
+	// Unreadable are the resolved dependencies, whose references could not be read, they are still listed in Files
+	Unreadable []UnreadableModel
+}
+
+// UnreadableModel ...
+type UnreadableModel struct {
+	Pth string
+	Err error
+}
+
+// isFrameworkAssembly reports whether the assembly is expected to be provided by the runtime.
+func isFrameworkAssembly(name string) bool {
+	switch name {
+	case "mscorlib", "netstandard", "System", "Microsoft.CSharp", "Microsoft.VisualBasic":
+		return true
+	}
+	return strings.HasPrefix(name, "System.") || strings.HasPrefix(name, "Mono.") || strings.HasPrefix(name, "Microsoft.Win32.")
+}
+
+// Dependencies walks the AssemblyRef table of the assembly transitively
+// and resolves the referenced assemblies in the assembly's directory.
+// It fails if the assembly can not be read, a dependency which can not be read is listed in Unreadable.
+func Dependencies(pth string) (DependenciesModel, error) {
+	dir := filepath.Dir(pth)
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return DependenciesModel{}, err
+	}
+
+	// assembly file names are matched case insensitively, like the runtime does on case insensitive file systems
+	filesByLowerName := map[string]string{}
+	for _, entry := range entries {
+		filesByLowerName[strings.ToLower(entry.Name())] = entry.Name()
+	}
+
+	resolve := func(name string) string {
+		for _, ext := range []string{".dll", ".exe"} {
+			if fileName, ok := filesByLowerName[strings.ToLower(name+ext)]; ok {
+				return filepath.Join(dir, fileName)
+			}
+		}
+		return ""
+	}
+
+	dependencies := DependenciesModel{}
+	resolved := map[string]bool{}
+	missing := map[string]bool{}
+
+	queue := []string{pth}
+	resolved[strings.ToLower(filepath.Base(pth))] = true
+	for len(queue) > 0 {
+		assemblyPth := queue[0]
+		queue = queue[1:]
+
+		dependencies.Files = append(dependencies.Files, assemblyPth)
+		dependencies.Files = append(dependencies.Files, companionFiles(assemblyPth, filesByLowerName)...)
+
+		model, err := Open(assemblyPth)
+		if err != nil {
+			if assemblyPth == pth {
+				return DependenciesModel{}, err
+			}
+			dependencies.Unreadable = append(dependencies.Unreadable, UnreadableModel{Pth: assemblyPth, Err: err})
+			continue
+		}
+
+		for _, reference := range model.References() {
+			referencePth := resolve(reference.Name)
+			if referencePth == "" {
+				if !isFrameworkAssembly(reference.Name) && !missing[reference.Name] {
+					missing[reference.Name] = true
+					dependencies.Missing = append(dependencies.Missing, MissingReferenceModel{
+						Reference:  reference,
+						ReferredBy: model.Name,
+					})
+				}
+				continue
+			}
+
+			key := strings.ToLower(filepath.Base(referencePth))
+			if resolved[key] {
+				continue
+			}
+			resolved[key] = true
+			queue = append(queue, referencePth)
+		}
+	}
+
+	sort.Strings(dependencies.Files)
+	sort.Slice(dependencies.Missing, func(i, j int) bool {
+		return dependencies.Missing[i].Reference.Name < dependencies.Missing[j].Reference.Name
+	})
+	sort.Slice(dependencies.Unreadable, func(i, j int) bool {
+		return dependencies.Unreadable[i].Pth < dependencies.Unreadable[j].Pth
+	})
+
+	return dependencies, nil
+}
+
+// companionFiles returns the debug symbols, config and satellite assemblies belonging to the assembly.
+func companionFiles(pth string, filesByLowerName map[string]string) []string {
+	dir := filepath.Dir(pth)
+	fileName := filepath.Base(pth)
+	baseName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+
+	files := []string{}
+	for _, companion := range []string{baseName + ".pdb", fileName + ".mdb", fileName + ".config"} {
+		if name, ok := filesByLowerName[strings.ToLower(companion)]; ok {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+
+	satellites, err := filepath.Glob(filepath.Join(dir, "*", baseName+".resources.dll"))
+	if err == nil {
+		for _, satellite := range satellites {
+			if info, err := os.Stat(satellite); err == nil && !info.IsDir() {
+				files = append(files, satellite)
+			}
+		}
+	}
+
+	return files
+}
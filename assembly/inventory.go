@@ -0,0 +1,279 @@
+package assembly
+
+import (
+	"sort"
+)
+
+const (
+	typeAttributesInterface = 0x20
+	typeAttributesAbstract  = 0x80
+)
+
+// TestModel ...
+type TestModel struct {
+	Name       string   `json:"name"`
+	Categories []string `json:"categories,omitempty"`
+	Cases      int      `json:"cases"`
+	Ignored    bool     `json:"ignored,omitempty"`
+}
+
+// FixtureModel ...
+type FixtureModel struct {
+	Namespace  string      `json:"namespace"`
+	Name       string      `json:"name"`
+	FullName   string      `json:"full_name"`
+	Categories []string    `json:"categories,omitempty"`
+	Instances  int         `json:"instances"`
+	Ignored    bool        `json:"ignored,omitempty"`
+	Tests      []TestModel `json:"tests"`
+}
+
+// InventoryModel ...
+type InventoryModel struct {
+	Assembly string         `json:"assembly"`
+	Fixtures []FixtureModel `json:"fixtures"`
+}
+
+// TestCount returns the number of test cases NUnit will run.
+func (fixture FixtureModel) TestCount() int {
+	if fixture.Ignored {
+		return 0
+	}
+
+	count := 0
+	for _, test := range fixture.Tests {
+		if !test.Ignored {
+			count += test.Cases
+		}
+	}
+	return count * fixture.Instances
+}
+
+// TestCount returns the number of test cases NUnit will run.
+func (inventory InventoryModel) TestCount() int {
+	count := 0
+	for _, fixture := range inventory.Fixtures {
+		count += fixture.TestCount()
+	}
+	return count
+}
+
+type attributeModel struct {
+	name  string
+	value []byte
+}
+
+type attributeTarget struct {
+	table int
+	row   uint32
+}
+
+// TestInventory enumerates the NUnit test fixtures, tests and categories of the assembly.
+func (model Model) TestInventory() InventoryModel {
+	md := model.md
+	attributes := model.attributes()
+
+	typeCount := md.rowCount(tableTypeDef)
+	fullNames := map[uint32]string{}
+	enclosing := map[uint32]uint32{}
+	for row := uint32(1); row <= md.rowCount(tableNestedClass); row++ {
+		enclosing[md.cell(tableNestedClass, row, 0)] = md.cell(tableNestedClass, row, 1)
+	}
+
+	var fullName func(row uint32, depth int) string
+	fullName = func(row uint32, depth int) string {
+		if name, ok := fullNames[row]; ok {
+			return name
+		}
+
+		name := md.string(md.cell(tableTypeDef, row, 1))
+		if parent, ok := enclosing[row]; ok && depth < 16 {
+			name = fullName(parent, depth+1) + "+" + name
+		} else if namespace := md.string(md.cell(tableTypeDef, row, 2)); namespace != "" {
+			name = namespace + "." + name
+		}
+		fullNames[row] = name
+		return name
+	}
+
+	inventory := InventoryModel{Assembly: model.Name, Fixtures: []FixtureModel{}}
+
+	// the first row is the <Module> pseudo class
+	for row := uint32(2); row <= typeCount; row++ {
+		flags := md.cell(tableTypeDef, row, 0)
+		if flags&(typeAttributesInterface|typeAttributesAbstract) != 0 {
+			continue
+		}
+
+		fixture := FixtureModel{
+			Namespace: md.string(md.cell(tableTypeDef, row, 2)),
+			Name:      md.string(md.cell(tableTypeDef, row, 1)),
+			FullName:  fullName(row, 0),
+			Tests:     []TestModel{},
+		}
+
+		fixtureAttributes := 0
+		for _, attribute := range attributes[attributeTarget{tableTypeDef, row}] {
+			switch attribute.name {
+			case "TestFixtureAttribute":
+				fixtureAttributes++
+			case "CategoryAttribute":
+				if category, ok := categoryValue(attribute.value); ok {
+					fixture.Categories = append(fixture.Categories, category)
+				}
+			case "IgnoreAttribute", "ExplicitAttribute":
+				fixture.Ignored = true
+			}
+		}
+		fixture.Instances = fixtureAttributes
+		if fixture.Instances == 0 {
+			fixture.Instances = 1
+		}
+
+		// tests declared in base classes of the same assembly are run as part of the derived fixture
+		seen := map[uint32]bool{}
+		for typeRow := row; typeRow != 0 && !seen[typeRow]; typeRow = model.baseTypeDef(typeRow) {
+			seen[typeRow] = true
+			fixture.Tests = append(fixture.Tests, model.tests(typeRow, attributes)...)
+		}
+
+		if len(fixture.Tests) == 0 && fixtureAttributes == 0 {
+			continue
+		}
+
+		sort.Slice(fixture.Tests, func(i, j int) bool { return fixture.Tests[i].Name < fixture.Tests[j].Name })
+		inventory.Fixtures = append(inventory.Fixtures, fixture)
+	}
+
+	sort.Slice(inventory.Fixtures, func(i, j int) bool { return inventory.Fixtures[i].FullName < inventory.Fixtures[j].FullName })
+
+	return inventory
+}
+
+func (model Model) tests(typeRow uint32, attributes map[attributeTarget][]attributeModel) []TestModel {
+	md := model.md
+	first, last := model.methodRange(typeRow)
+
+	tests := []TestModel{}
+	for methodRow := first; methodRow < last; methodRow++ {
+		test := TestModel{Name: md.string(md.cell(tableMethodDef, methodRow, 3))}
+
+		isTest := false
+		cases := 0
+		for _, attribute := range attributes[attributeTarget{tableMethodDef, methodRow}] {
+			switch attribute.name {
+			case "TestAttribute":
+				isTest = true
+			case "TestCaseAttribute", "TestCaseSourceAttribute":
+				isTest = true
+				cases++
+			case "CategoryAttribute":
+				if category, ok := categoryValue(attribute.value); ok {
+					test.Categories = append(test.Categories, category)
+				}
+			case "IgnoreAttribute", "ExplicitAttribute":
+				test.Ignored = true
+			}
+		}
+
+		if !isTest {
+			continue
+		}
+
+		test.Cases = cases
+		if test.Cases == 0 {
+			test.Cases = 1
+		}
+		tests = append(tests, test)
+	}
+	return tests
+}
+
+// methodRange returns the MethodDef rows [first, last) owned by the type.
+func (model Model) methodRange(typeRow uint32) (uint32, uint32) {
+	md := model.md
+	first := md.cell(tableTypeDef, typeRow, 5)
+	last := md.rowCount(tableMethodDef) + 1
+	if typeRow < md.rowCount(tableTypeDef) {
+		last = md.cell(tableTypeDef, typeRow+1, 5)
+	}
+	if first == 0 || last < first {
+		return 0, 0
+	}
+	return first, last
+}
+
+func (model Model) methodOwner(methodRow uint32) uint32 {
+	md := model.md
+	typeCount := md.rowCount(tableTypeDef)
+
+	// MethodList is ascending, find the last type whose list starts at or before the method
+	i := sort.Search(int(typeCount), func(i int) bool {
+		return md.cell(tableTypeDef, uint32(i)+1, 5) > methodRow
+	})
+	return uint32(i)
+}
+
+func (model Model) baseTypeDef(typeRow uint32) uint32 {
+	table, row := model.md.codedCell(tableTypeDef, typeRow, 3)
+	if table != tableTypeDef {
+		return 0
+	}
+	return row
+}
+
+// typeName returns the name of a TypeDef or TypeRef row.
+func (model Model) typeName(table int, row uint32) string {
+	switch table {
+	case tableTypeDef, tableTypeRef:
+		return model.md.string(model.md.cell(table, row, 1))
+	}
+	return ""
+}
+
+func (model Model) attributes() map[attributeTarget][]attributeModel {
+	md := model.md
+	attributes := map[attributeTarget][]attributeModel{}
+
+	for row := uint32(1); row <= md.rowCount(tableCustomAttribute); row++ {
+		parentTable, parentRow := md.codedCell(tableCustomAttribute, row, 0)
+		if parentTable != tableTypeDef && parentTable != tableMethodDef {
+			continue
+		}
+
+		name := ""
+		constructorTable, constructorRow := md.codedCell(tableCustomAttribute, row, 1)
+		switch constructorTable {
+		case tableMemberRef:
+			classTable, classRow := md.codedCell(tableMemberRef, constructorRow, 0)
+			name = model.typeName(classTable, classRow)
+		case tableMethodDef:
+			name = model.typeName(tableTypeDef, model.methodOwner(constructorRow))
+		}
+		if name == "" {
+			continue
+		}
+
+		target := attributeTarget{parentTable, parentRow}
+		attributes[target] = append(attributes[target], attributeModel{
+			name:  name,
+			value: md.blob(md.cell(tableCustomAttribute, row, 2)),
+		})
+	}
+
+	return attributes
+}
+
+// categoryValue decodes the single string argument of a [Category("...")] attribute blob.
+func categoryValue(value []byte) (string, bool) {
+	// prolog: 0x0001
+	if len(value) < 3 || value[0] != 0x01 || value[1] != 0x00 || value[2] == 0xff {
+		return "", false
+	}
+
+	length, size, ok := compressedUint(value[2:])
+	if !ok || 2+size+int(length) > len(value) {
+		return "", false
+	}
+	return string(value[2+size : 2+size+int(length)]), true
+}
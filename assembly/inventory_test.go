@@ -0,0 +1,143 @@
+package assembly
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTestInventory(t *testing.T) {
+	model, err := Open(fixtureAssemblyPth)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := InventoryModel{
+		Assembly: "Sample.UITests",
+		Fixtures: []FixtureModel{
+			{
+				Namespace: "Sample.UITests",
+				Name:      "CheckoutTests",
+				FullName:  "Sample.UITests.CheckoutTests",
+				Instances: 1,
+				Ignored:   true,
+				Tests:     []TestModel{{Name: "CanPay", Cases: 1}},
+			},
+			{
+				Namespace:  "Sample.UITests",
+				Name:       "LoginTests",
+				FullName:   "Sample.UITests.LoginTests",
+				Categories: []string{"Login"},
+				Instances:  2,
+				Tests: []TestModel{
+					// inherited from the abstract BaseTests
+					{Name: "AppLaunches", Cases: 1},
+					{Name: "CanLogin", Categories: []string{"Smoke"}, Cases: 1},
+					{Name: "RejectsInvalidPassword", Cases: 3},
+					{Name: "RemembersUser", Cases: 1, Ignored: true},
+				},
+			},
+			{
+				Namespace:  "Sample.UITests",
+				Name:       "SettingsTests",
+				FullName:   "Sample.UITests.SettingsTests",
+				Categories: []string{"Settings"},
+				Instances:  1,
+				Tests: []TestModel{
+					{Name: "CanOpenSettings", Cases: 1},
+					{Name: "ChangesFontSize", Categories: []string{"Slow"}, Cases: 2},
+				},
+			},
+			{
+				Name:      "Dialogs",
+				FullName:  "Sample.UITests.SettingsTests+Dialogs",
+				Instances: 1,
+				Tests:     []TestModel{{Name: "CanClose", Cases: 1}},
+			},
+		},
+	}
+
+	inventory := model.TestInventory()
+	if !reflect.DeepEqual(inventory, want) {
+		t.Fatalf("inventory:\ngot:  %+v\nwant: %+v", inventory, want)
+	}
+
+	// LoginTests: 2 instances * (1 + 1 + 3), SettingsTests: 1 + 2, Dialogs: 1, CheckoutTests is ignored
+	if count := inventory.TestCount(); count != 14 {
+		t.Errorf("test count: got %d, want 14", count)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	dependencies, err := Dependencies(fixtureAssemblyPth)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := []string{fixtureAssemblyPth}; !reflect.DeepEqual(dependencies.Files, want) {
+		t.Errorf("files: got %v, want %v", dependencies.Files, want)
+	}
+
+	// System.Runtime is provided by the runtime
+	wantMissing := []MissingReferenceModel{
+		{Reference: ReferenceModel{Name: "nunit.framework", Version: "3.6.1.0"}, ReferredBy: "Sample.UITests"},
+	}
+	if !reflect.DeepEqual(dependencies.Missing, wantMissing) {
+		t.Errorf("missing:\ngot:  %+v\nwant: %+v", dependencies.Missing, wantMissing)
+	}
+}
+
+func TestDependenciesUnreadable(t *testing.T) {
+	content, err := ioutil.ReadFile(fixtureAssemblyPth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "assembly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	assemblyPth := filepath.Join(dir, "Sample.UITests.dll")
+	if err := ioutil.WriteFile(assemblyPth, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// resolved by name, but not an assembly
+	unreadablePth := filepath.Join(dir, "nunit.framework.dll")
+	if err := ioutil.WriteFile(unreadablePth, []byte("not an assembly"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dependencies, err := Dependencies(assemblyPth)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := []string{assemblyPth, unreadablePth}; !reflect.DeepEqual(dependencies.Files, want) {
+		t.Errorf("files: got %v, want %v", dependencies.Files, want)
+	}
+	if len(dependencies.Missing) != 0 {
+		t.Errorf("missing: got %+v, want none", dependencies.Missing)
+	}
+	if len(dependencies.Unreadable) != 1 || dependencies.Unreadable[0].Pth != unreadablePth {
+		t.Fatalf("unreadable: got %+v, want %s", dependencies.Unreadable, unreadablePth)
+	}
+	if err := dependencies.Unreadable[0].Err; !strings.Contains(err.Error(), "failed to open assembly") {
+		t.Errorf("unreadable error: got %q, want failed to open assembly", err)
+	}
+
+	if err := ioutil.WriteFile(assemblyPth, []byte("not an assembly"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Dependencies(assemblyPth); err == nil {
+		t.Errorf("expected error for an unreadable assembly")
+	}
+}
@@ -0,0 +1,334 @@
+package assembly
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	metadataSignature        = 0x424a5342
+	comDescriptorDirectory   = 14
+	cliHeaderMetadataOffset  = 8
+	heapSizesLargeStrings    = 0x01
+	heapSizesLargeGUIDs      = 0x02
+	heapSizesLargeBlobs      = 0x04
+	heapSizesExtraData       = 0x40
+	maxCompressedUintByteLen = 4
+)
+
+type table struct {
+	rows       uint32
+	offset     int
+	rowSize    int
+	colOffsets []int
+	colSizes   []int
+}
+
+type metadata struct {
+	strings []byte
+	blobs   []byte
+	guids   []byte
+	tables  []byte
+
+	stringIndexSize int
+	guidIndexSize   int
+	blobIndexSize   int
+
+	tableInfos [tableCount]table
+}
+
+// dataDirectoryCount clamps the data directory count of the optional header to the directories it can hold.
+func dataDirectoryCount(numberOfRvaAndSizes uint32, capacity int) int {
+	if int(numberOfRvaAndSizes) < capacity {
+		return int(numberOfRvaAndSizes)
+	}
+	return capacity
+}
+
+// metadataFromPE locates the metadata of a .NET assembly via the CLI header of the PE file.
+func metadataFromPE(file *pe.File) ([]byte, error) {
+	var dataDirectories []pe.DataDirectory
+	switch header := file.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		dataDirectories = header.DataDirectory[:dataDirectoryCount(header.NumberOfRvaAndSizes, len(header.DataDirectory))]
+	case *pe.OptionalHeader64:
+		dataDirectories = header.DataDirectory[:dataDirectoryCount(header.NumberOfRvaAndSizes, len(header.DataDirectory))]
+	default:
+		return nil, fmt.Errorf("no optional header found")
+	}
+
+	if len(dataDirectories) <= comDescriptorDirectory || dataDirectories[comDescriptorDirectory].VirtualAddress == 0 {
+		return nil, fmt.Errorf("not a .NET assembly, no CLI header found")
+	}
+	cliDirectory := dataDirectories[comDescriptorDirectory]
+
+	cliHeader, err := readRVA(file, cliDirectory.VirtualAddress, cliDirectory.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CLI header, error: %s", err)
+	}
+	if len(cliHeader) < cliHeaderMetadataOffset+8 {
+		return nil, fmt.Errorf("invalid CLI header")
+	}
+
+	metadataRVA := binary.LittleEndian.Uint32(cliHeader[cliHeaderMetadataOffset:])
+	metadataSize := binary.LittleEndian.Uint32(cliHeader[cliHeaderMetadataOffset+4:])
+
+	return readRVA(file, metadataRVA, metadataSize)
+}
+
+func readRVA(file *pe.File, rva, size uint32) ([]byte, error) {
+	for _, section := range file.Sections {
+		sectionSize := section.VirtualSize
+		if section.Size > sectionSize {
+			sectionSize = section.Size
+		}
+		if rva < section.VirtualAddress || rva >= section.VirtualAddress+sectionSize {
+			continue
+		}
+
+		data, err := section.Data()
+		if err != nil {
+			return nil, err
+		}
+
+		start := uint64(rva - section.VirtualAddress)
+		end := start + uint64(size)
+		if end > uint64(len(data)) {
+			return nil, fmt.Errorf("rva 0x%x (size: %d) out of section (%s) bounds", rva, size, section.Name)
+		}
+		return data[start:end], nil
+	}
+	return nil, fmt.Errorf("no section contains rva 0x%x", rva)
+}
+
+func parseMetadata(content []byte) (*metadata, error) {
+	if len(content) < 16 || binary.LittleEndian.Uint32(content) != metadataSignature {
+		return nil, fmt.Errorf("invalid metadata signature")
+	}
+
+	versionLength := int(binary.LittleEndian.Uint32(content[12:]))
+	offset := 16 + versionLength
+	if versionLength < 0 || offset+4 > len(content) {
+		return nil, fmt.Errorf("invalid metadata header")
+	}
+
+	streamCount := int(binary.LittleEndian.Uint16(content[offset+2:]))
+	offset += 4
+
+	md := &metadata{}
+	for i := 0; i < streamCount; i++ {
+		if offset+8 > len(content) {
+			return nil, fmt.Errorf("invalid metadata stream header")
+		}
+		streamOffset := uint64(binary.LittleEndian.Uint32(content[offset:]))
+		streamSize := uint64(binary.LittleEndian.Uint32(content[offset+4:]))
+		offset += 8
+
+		nameEnd := bytes.IndexByte(content[offset:], 0)
+		if nameEnd == -1 {
+			return nil, fmt.Errorf("invalid metadata stream name")
+		}
+		name := string(content[offset : offset+nameEnd])
+		// the name is null terminated and padded to 4 bytes
+		offset += (nameEnd + 4) &^ 3
+
+		if streamOffset+streamSize > uint64(len(content)) {
+			return nil, fmt.Errorf("metadata stream (%s) out of bounds", name)
+		}
+		stream := content[streamOffset : streamOffset+streamSize]
+
+		switch name {
+		case "#Strings":
+			md.strings = stream
+		case "#Blob":
+			md.blobs = stream
+		case "#GUID":
+			md.guids = stream
+		case "#~", "#-":
+			md.tables = stream
+		}
+	}
+
+	if md.tables == nil {
+		return nil, fmt.Errorf("no metadata tables stream found")
+	}
+
+	return md, md.parseTablesHeader()
+}
+
+func (md *metadata) parseTablesHeader() error {
+	if len(md.tables) < 24 {
+		return fmt.Errorf("invalid metadata tables header")
+	}
+
+	heapSizes := md.tables[6]
+	valid := binary.LittleEndian.Uint64(md.tables[8:])
+
+	md.stringIndexSize, md.guidIndexSize, md.blobIndexSize = 2, 2, 2
+	if heapSizes&heapSizesLargeStrings != 0 {
+		md.stringIndexSize = 4
+	}
+	if heapSizes&heapSizesLargeGUIDs != 0 {
+		md.guidIndexSize = 4
+	}
+	if heapSizes&heapSizesLargeBlobs != 0 {
+		md.blobIndexSize = 4
+	}
+
+	offset := 24
+	for id := 0; id < 64; id++ {
+		if valid&(uint64(1)<<uint(id)) == 0 {
+			continue
+		}
+		if offset+4 > len(md.tables) {
+			return fmt.Errorf("invalid metadata table row counts")
+		}
+		rows := binary.LittleEndian.Uint32(md.tables[offset:])
+		offset += 4
+
+		if id >= tableCount {
+			// tables following the known ones do not affect the known tables' layout
+			continue
+		}
+		md.tableInfos[id].rows = rows
+	}
+
+	if heapSizes&heapSizesExtraData != 0 {
+		offset += 4
+	}
+
+	for id := 0; id < tableCount; id++ {
+		info := &md.tableInfos[id]
+		info.offset = offset
+
+		for _, col := range schemas[id] {
+			size := md.columnSize(col)
+			info.colOffsets = append(info.colOffsets, info.rowSize)
+			info.colSizes = append(info.colSizes, size)
+			info.rowSize += size
+		}
+
+		offset += info.rowSize * int(info.rows)
+		if offset > len(md.tables) {
+			return fmt.Errorf("metadata table (0x%x) out of bounds", id)
+		}
+	}
+
+	return nil
+}
+
+func (md *metadata) columnSize(col column) int {
+	switch col.kind {
+	case columnFixed:
+		return col.size
+	case columnString:
+		return md.stringIndexSize
+	case columnGUID:
+		return md.guidIndexSize
+	case columnBlob:
+		return md.blobIndexSize
+	case columnTable:
+		if md.tableInfos[col.table].rows < 1<<16 {
+			return 2
+		}
+		return 4
+	case columnCoded:
+		maxRows := uint32(0)
+		for _, id := range col.coded.tables {
+			if id != unusedTable && md.tableInfos[id].rows > maxRows {
+				maxRows = md.tableInfos[id].rows
+			}
+		}
+		if maxRows < uint32(1)<<(16-col.coded.bits) {
+			return 2
+		}
+		return 4
+	}
+	return 0
+}
+
+func (md *metadata) rowCount(id int) uint32 {
+	return md.tableInfos[id].rows
+}
+
+// cell returns the value of a column of a row (1 based).
+func (md *metadata) cell(id int, row uint32, col int) uint32 {
+	info := md.tableInfos[id]
+	if row == 0 || row > info.rows {
+		return 0
+	}
+
+	offset := info.offset + int(row-1)*info.rowSize + info.colOffsets[col]
+	switch info.colSizes[col] {
+	case 1:
+		return uint32(md.tables[offset])
+	case 2:
+		return uint32(binary.LittleEndian.Uint16(md.tables[offset:]))
+	default:
+		return binary.LittleEndian.Uint32(md.tables[offset:])
+	}
+}
+
+// codedCell decodes a coded index column into a table id and row.
+func (md *metadata) codedCell(id int, row uint32, col int) (int, uint32) {
+	value := md.cell(id, row, col)
+	index := schemas[id][col].coded
+
+	tag := value & (uint32(1)<<index.bits - 1)
+	if int(tag) >= len(index.tables) {
+		return unusedTable, 0
+	}
+	return index.tables[tag], value >> index.bits
+}
+
+func (md *metadata) string(index uint32) string {
+	if int(index) >= len(md.strings) {
+		return ""
+	}
+	end := bytes.IndexByte(md.strings[index:], 0)
+	if end == -1 {
+		return string(md.strings[index:])
+	}
+	return string(md.strings[index : int(index)+end])
+}
+
+func (md *metadata) blob(index uint32) []byte {
+	if int(index) >= len(md.blobs) {
+		return nil
+	}
+	length, size, ok := compressedUint(md.blobs[index:])
+	if !ok {
+		return nil
+	}
+	start := uint64(index) + uint64(size)
+	end := start + uint64(length)
+	if end > uint64(len(md.blobs)) {
+		return nil
+	}
+	return md.blobs[start:end]
+}
+
+// compressedUint decodes an unsigned integer compressed as described in ECMA-335 II.23.2.
+func compressedUint(data []byte) (uint32, int, bool) {
+	if len(data) == 0 {
+		return 0, 0, false
+	}
+
+	switch {
+	case data[0]&0x80 == 0:
+		return uint32(data[0]), 1, true
+	case data[0]&0xc0 == 0x80:
+		if len(data) < 2 {
+			return 0, 0, false
+		}
+		return uint32(data[0]&0x3f)<<8 | uint32(data[1]), 2, true
+	case data[0]&0xe0 == 0xc0:
+		if len(data) < maxCompressedUintByteLen {
+			return 0, 0, false
+		}
+		return uint32(data[0]&0x1f)<<24 | uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3]), 4, true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,75 @@
+package assembly
+
+import (
+	"debug/pe"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const fixtureAssemblyPth = "testdata/Sample.UITests.dll"
+
+func TestOpen(t *testing.T) {
+	model, err := Open(fixtureAssemblyPth)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if model.Name != "Sample.UITests" || model.Version != "1.0.0.0" {
+		t.Errorf("got %s %s, want Sample.UITests 1.0.0.0", model.Name, model.Version)
+	}
+
+	wantReferences := []ReferenceModel{
+		{Name: "System.Runtime", Version: "8.0.0.0"},
+		{Name: "nunit.framework", Version: "3.6.1.0"},
+	}
+	if references := model.References(); !reflect.DeepEqual(references, wantReferences) {
+		t.Errorf("references:\ngot:  %+v\nwant: %+v", references, wantReferences)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	content, err := ioutil.ReadFile(fixtureAssemblyPth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "assembly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	for name, corrupt := range map[string][]byte{
+		"Empty.dll":     {},
+		"Truncated.dll": content[:len(content)/2],
+		"NotPE.dll":     []byte("not an assembly"),
+	} {
+		pth := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(pth, corrupt, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(pth); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestMetadataFromPEDataDirectoryCount(t *testing.T) {
+	// NumberOfRvaAndSizes is read from the file, it may exceed the size of the DataDirectory array
+	for _, header := range []interface{}{
+		&pe.OptionalHeader32{NumberOfRvaAndSizes: 0xffff},
+		&pe.OptionalHeader64{NumberOfRvaAndSizes: 0xffff},
+		&pe.OptionalHeader32{NumberOfRvaAndSizes: 3},
+	} {
+		if _, err := metadataFromPE(&pe.File{OptionalHeader: header}); err == nil {
+			t.Errorf("%T: expected error", header)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package assembly
+
+// Metadata table ids, ECMA-335 II.22
+const (
+	tableModule                 = 0x00
+	tableTypeRef                = 0x01
+	tableTypeDef                = 0x02
+	tableFieldPtr               = 0x03
+	tableField                  = 0x04
+	tableMethodPtr              = 0x05
+	tableMethodDef              = 0x06
+	tableParamPtr               = 0x07
+	tableParam                  = 0x08
+	tableInterfaceImpl          = 0x09
+	tableMemberRef              = 0x0a
+	tableConstant               = 0x0b
+	tableCustomAttribute        = 0x0c
+	tableFieldMarshal           = 0x0d
+	tableDeclSecurity           = 0x0e
+	tableClassLayout            = 0x0f
+	tableFieldLayout            = 0x10
+	tableStandAloneSig          = 0x11
+	tableEventMap               = 0x12
+	tableEventPtr               = 0x13
+	tableEvent                  = 0x14
+	tablePropertyMap            = 0x15
+	tablePropertyPtr            = 0x16
+	tableProperty               = 0x17
+	tableMethodSemantics        = 0x18
+	tableMethodImpl             = 0x19
+	tableModuleRef              = 0x1a
+	tableTypeSpec               = 0x1b
+	tableImplMap                = 0x1c
+	tableFieldRVA               = 0x1d
+	tableEncLog                 = 0x1e
+	tableEncMap                 = 0x1f
+	tableAssembly               = 0x20
+	tableAssemblyProcessor      = 0x21
+	tableAssemblyOS             = 0x22
+	tableAssemblyRef            = 0x23
+	tableAssemblyRefProcessor   = 0x24
+	tableAssemblyRefOS          = 0x25
+	tableFile                   = 0x26
+	tableExportedType           = 0x27
+	tableManifestResource       = 0x28
+	tableNestedClass            = 0x29
+	tableGenericParam           = 0x2a
+	tableMethodSpec             = 0x2b
+	tableGenericParamConstraint = 0x2c
+
+	tableCount = 0x2d
+)
+
+const unusedTable = -1
+
+type codedIndex struct {
+	bits   uint
+	tables []int
+}
+
+// Coded index kinds, ECMA-335 II.24.2.6
+var (
+	codedTypeDefOrRef       = codedIndex{2, []int{tableTypeDef, tableTypeRef, tableTypeSpec}}
+	codedHasConstant        = codedIndex{2, []int{tableField, tableParam, tableProperty}}
+	codedHasCustomAttribute = codedIndex{5, []int{
+		tableMethodDef, tableField, tableTypeRef, tableTypeDef, tableParam, tableInterfaceImpl, tableMemberRef,
+		tableModule, tableDeclSecurity, tableProperty, tableEvent, tableStandAloneSig, tableModuleRef, tableTypeSpec,
+		tableAssembly, tableAssemblyRef, tableFile, tableExportedType, tableManifestResource, tableGenericParam,
+		tableGenericParamConstraint, tableMethodSpec,
+	}}
+	codedHasFieldMarshal     = codedIndex{1, []int{tableField, tableParam}}
+	codedHasDeclSecurity     = codedIndex{2, []int{tableTypeDef, tableMethodDef, tableAssembly}}
+	codedMemberRefParent     = codedIndex{3, []int{tableTypeDef, tableTypeRef, tableModuleRef, tableMethodDef, tableTypeSpec}}
+	codedHasSemantics        = codedIndex{1, []int{tableEvent, tableProperty}}
+	codedMethodDefOrRef      = codedIndex{1, []int{tableMethodDef, tableMemberRef}}
+	codedMemberForwarded     = codedIndex{1, []int{tableField, tableMethodDef}}
+	codedImplementation      = codedIndex{2, []int{tableFile, tableAssemblyRef, tableExportedType}}
+	codedCustomAttributeType = codedIndex{3, []int{unusedTable, unusedTable, tableMethodDef, tableMemberRef, unusedTable}}
+	codedResolutionScope     = codedIndex{2, []int{tableModule, tableModuleRef, tableAssemblyRef, tableTypeRef}}
+	codedTypeOrMethodDef     = codedIndex{1, []int{tableTypeDef, tableMethodDef}}
+)
+
+type columnKind int
+
+const (
+	columnFixed columnKind = iota
+	columnString
+	columnGUID
+	columnBlob
+	columnTable
+	columnCoded
+)
+
+type column struct {
+	kind  columnKind
+	size  int
+	table int
+	coded codedIndex
+}
+
+func fixed(size int) column         { return column{kind: columnFixed, size: size} }
+func index(table int) column        { return column{kind: columnTable, table: table} }
+func coded(index codedIndex) column { return column{kind: columnCoded, coded: index} }
+
+var (
+	str  = column{kind: columnString}
+	guid = column{kind: columnGUID}
+	blob = column{kind: columnBlob}
+)
+
+// Table schemas, ECMA-335 II.22
+var schemas = [tableCount][]column{
+	tableModule:                 {fixed(2), str, guid, guid, guid},
+	tableTypeRef:                {coded(codedResolutionScope), str, str},
+	tableTypeDef:                {fixed(4), str, str, coded(codedTypeDefOrRef), index(tableField), index(tableMethodDef)},
+	tableFieldPtr:               {index(tableField)},
+	tableField:                  {fixed(2), str, blob},
+	tableMethodPtr:              {index(tableMethodDef)},
+	tableMethodDef:              {fixed(4), fixed(2), fixed(2), str, blob, index(tableParam)},
+	tableParamPtr:               {index(tableParam)},
+	tableParam:                  {fixed(2), fixed(2), str},
+	tableInterfaceImpl:          {index(tableTypeDef), coded(codedTypeDefOrRef)},
+	tableMemberRef:              {coded(codedMemberRefParent), str, blob},
+	tableConstant:               {fixed(2), coded(codedHasConstant), blob},
+	tableCustomAttribute:        {coded(codedHasCustomAttribute), coded(codedCustomAttributeType), blob},
+	tableFieldMarshal:           {coded(codedHasFieldMarshal), blob},
+	tableDeclSecurity:           {fixed(2), coded(codedHasDeclSecurity), blob},
+	tableClassLayout:            {fixed(2), fixed(4), index(tableTypeDef)},
+	tableFieldLayout:            {fixed(4), index(tableField)},
+	tableStandAloneSig:          {blob},
+	tableEventMap:               {index(tableTypeDef), index(tableEvent)},
+	tableEventPtr:               {index(tableEvent)},
+	tableEvent:                  {fixed(2), str, coded(codedTypeDefOrRef)},
+	tablePropertyMap:            {index(tableTypeDef), index(tableProperty)},
+	tablePropertyPtr:            {index(tableProperty)},
+	tableProperty:               {fixed(2), str, blob},
+	tableMethodSemantics:        {fixed(2), index(tableMethodDef), coded(codedHasSemantics)},
+	tableMethodImpl:             {index(tableTypeDef), coded(codedMethodDefOrRef), coded(codedMethodDefOrRef)},
+	tableModuleRef:              {str},
+	tableTypeSpec:               {blob},
+	tableImplMap:                {fixed(2), coded(codedMemberForwarded), str, index(tableModuleRef)},
+	tableFieldRVA:               {fixed(4), index(tableField)},
+	tableEncLog:                 {fixed(4), fixed(4)},
+	tableEncMap:                 {fixed(4)},
+	tableAssembly:               {fixed(4), fixed(2), fixed(2), fixed(2), fixed(2), fixed(4), blob, str, str},
+	tableAssemblyProcessor:      {fixed(4)},
+	tableAssemblyOS:             {fixed(4), fixed(4), fixed(4)},
+	tableAssemblyRef:            {fixed(2), fixed(2), fixed(2), fixed(2), fixed(4), blob, str, str, blob},
+	tableAssemblyRefProcessor:   {fixed(4), index(tableAssemblyRef)},
+	tableAssemblyRefOS:          {fixed(4), fixed(4), fixed(4), index(tableAssemblyRef)},
+	tableFile:                   {fixed(4), str, blob},
+	tableExportedType:           {fixed(4), fixed(4), str, str, coded(codedImplementation)},
+	tableManifestResource:       {fixed(4), fixed(4), str, coded(codedImplementation)},
+	tableNestedClass:            {index(tableTypeDef), index(tableTypeDef)},
+	tableGenericParam:           {fixed(2), fixed(2), coded(codedTypeOrMethodDef), str},
+	tableMethodSpec:             {coded(codedMethodDefOrRef), blob},
+	tableGenericParamConstraint: {index(tableGenericParam), coded(codedTypeDefOrRef)},
+}
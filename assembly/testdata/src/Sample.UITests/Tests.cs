@@ -0,0 +1,67 @@
+// The assembly/testdata/Sample.UITests.dll fixture is built from this project:
+// dotnet build -c Release -o out, the nunit project stubs the NUnit attributes.
+
+using NUnit.Framework;
+
+namespace Sample.UITests
+{
+	public abstract class BaseTests
+	{
+		[Test]
+		public void AppLaunches() { }
+	}
+
+	[TestFixture("iPhone")]
+	[TestFixture("iPad")]
+	[Category("Login")]
+	public class LoginTests : BaseTests
+	{
+		public LoginTests(string device) { }
+
+		[Test]
+		[Category("Smoke")]
+		public void CanLogin() { }
+
+		[TestCase("")]
+		[TestCase("short")]
+		[TestCase("wrong password")]
+		public void RejectsInvalidPassword(string password) { }
+
+		[Test]
+		[Ignore("flaky")]
+		public void RemembersUser() { }
+
+		public void Helper() { }
+	}
+
+	[Category("Settings")]
+	public class SettingsTests
+	{
+		[Test]
+		public void CanOpenSettings() { }
+
+		[TestCase(12)]
+		[TestCase(16)]
+		[Category("Slow")]
+		public void ChangesFontSize(int size) { }
+
+		public class Dialogs
+		{
+			[Test]
+			public void CanClose() { }
+		}
+	}
+
+	[TestFixture]
+	[Ignore("work in progress")]
+	public class CheckoutTests
+	{
+		[Test]
+		public void CanPay() { }
+	}
+
+	public class Helpers
+	{
+		public void Wait() { }
+	}
+}
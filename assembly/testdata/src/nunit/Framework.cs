@@ -0,0 +1,32 @@
+using System;
+
+namespace NUnit.Framework
+{
+	[AttributeUsage(AttributeTargets.Class, AllowMultiple = true, Inherited = true)]
+	public class TestFixtureAttribute : Attribute
+	{
+		public TestFixtureAttribute() { }
+		public TestFixtureAttribute(params object[] arguments) { }
+	}
+
+	[AttributeUsage(AttributeTargets.Method)]
+	public class TestAttribute : Attribute { }
+
+	[AttributeUsage(AttributeTargets.Method, AllowMultiple = true)]
+	public class TestCaseAttribute : Attribute
+	{
+		public TestCaseAttribute(params object[] arguments) { }
+	}
+
+	[AttributeUsage(AttributeTargets.Class | AttributeTargets.Method, AllowMultiple = true)]
+	public class CategoryAttribute : Attribute
+	{
+		public CategoryAttribute(string name) { }
+	}
+
+	[AttributeUsage(AttributeTargets.Class | AttributeTargets.Method)]
+	public class IgnoreAttribute : Attribute
+	{
+		public IgnoreAttribute(string reason) { }
+	}
+}
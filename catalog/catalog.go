@@ -0,0 +1,163 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// FamilyIPhone ...
+	FamilyIPhone = "iphone"
+	// FamilyIPad ...
+	FamilyIPad = "ipad"
+)
+
+// UIDeviceFamily values of the Info.plist
+const (
+	deviceFamilyIPhone = 1
+	deviceFamilyIPad   = 2
+)
+
+// DeviceModel ...
+type DeviceModel struct {
+	Model     string `json:"model" yaml:"model"`
+	OSVersion string `json:"os_version,omitempty" yaml:"os_version,omitempty"`
+	// Family is iphone or ipad, if not set it is derived from the model.
+	Family string `json:"family,omitempty" yaml:"family,omitempty"`
+}
+
+// DeviceFamily returns the family of the device, iPod touches belong to the iphone family.
+func (device DeviceModel) DeviceFamily() string {
+	if device.Family != "" {
+		return strings.ToLower(device.Family)
+	}
+	if strings.HasPrefix(strings.ToLower(device.Model), FamilyIPad) {
+		return FamilyIPad
+	}
+	return FamilyIPhone
+}
+
+// String ...
+func (device DeviceModel) String() string {
+	if device.OSVersion == "" {
+		return device.Model
+	}
+	return fmt.Sprintf("%s, iOS %s", device.Model, device.OSVersion)
+}
+
+// IncompatibilityModel describes a device which can not install the app.
+type IncompatibilityModel struct {
+	Device DeviceModel
+	Reason string
+}
+
+// DeviceSetModel is a Test Cloud device selection and the devices it selects.
+type DeviceSetModel struct {
+	Name    string        `json:"name" yaml:"name"`
+	Devices []DeviceModel `json:"devices" yaml:"devices"`
+}
+
+// Model maps the Test Cloud device selection ids to the device sets they select.
+type Model map[string]DeviceSetModel
+
+// Open reads a device catalog, a YAML file if its extension is .yml or .yaml, a JSON file otherwise.
+func Open(pth string) (Model, error) {
+	content, err := ioutil.ReadFile(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog Model
+	switch strings.ToLower(filepath.Ext(pth)) {
+	case ".yml", ".yaml":
+		if err := yaml.Unmarshal(content, &catalog); err != nil {
+			return nil, fmt.Errorf("failed to parse device catalog as YAML, error: %s", err)
+		}
+	default:
+		if err := json.Unmarshal(content, &catalog); err != nil {
+			return nil, fmt.Errorf("failed to parse device catalog as JSON, error: %s", err)
+		}
+	}
+
+	for id, deviceSet := range catalog {
+		if len(deviceSet.Devices) == 0 {
+			return nil, fmt.Errorf("device set (%s) does not contain any device", id)
+		}
+		for i, device := range deviceSet.Devices {
+			if device.Model == "" {
+				return nil, fmt.Errorf("device set (%s): device #%d: model is not set", id, i+1)
+			}
+			if family := device.DeviceFamily(); family != FamilyIPhone && family != FamilyIPad {
+				return nil, fmt.Errorf("device set (%s): device #%d: invalid family (%s), available: %s, %s", id, i+1, device.Family, FamilyIPhone, FamilyIPad)
+			}
+		}
+	}
+
+	return catalog, nil
+}
+
+// DeviceSet returns the device set of the device selection id.
+func (catalog Model) DeviceSet(id string) (DeviceSetModel, bool) {
+	deviceSet, ok := catalog[id]
+	return deviceSet, ok
+}
+
+// Incompatibilities collects the devices of the set which can not install an app
+// with the given MinimumOSVersion and UIDeviceFamily.
+// iPhone only apps run on iPads, but iPad only apps can not be installed on iPhones.
+func (deviceSet DeviceSetModel) Incompatibilities(minimumOSVersion string, deviceFamilies []int64) []IncompatibilityModel {
+	iPhoneSupported := len(deviceFamilies) == 0
+	for _, family := range deviceFamilies {
+		if family == deviceFamilyIPhone {
+			iPhoneSupported = true
+		}
+	}
+
+	incompatibilities := []IncompatibilityModel{}
+	for _, device := range deviceSet.Devices {
+		if device.OSVersion != "" && minimumOSVersion != "" && CompareVersions(device.OSVersion, minimumOSVersion) < 0 {
+			incompatibilities = append(incompatibilities, IncompatibilityModel{
+				Device: device,
+				Reason: fmt.Sprintf("iOS %s is older than the app's MinimumOSVersion (%s)", device.OSVersion, minimumOSVersion),
+			})
+		} else if device.DeviceFamily() == FamilyIPhone && !iPhoneSupported {
+			incompatibilities = append(incompatibilities, IncompatibilityModel{
+				Device: device,
+				Reason: "the app supports iPad only",
+			})
+		}
+	}
+	return incompatibilities
+}
+
+// CompareVersions compares dot separated numeric versions, missing components count as 0.
+func CompareVersions(a, b string) int {
+	aComponents := strings.Split(a, ".")
+	bComponents := strings.Split(b, ".")
+	for i := 0; i < len(aComponents) || i < len(bComponents); i++ {
+		aValue, bValue := versionComponent(aComponents, i), versionComponent(bComponents, i)
+		if aValue < bValue {
+			return -1
+		} else if aValue > bValue {
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionComponent(components []string, i int) int {
+	if i >= len(components) {
+		return 0
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(components[i]))
+	if err != nil {
+		return 0
+	}
+	return value
+}
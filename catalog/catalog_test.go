@@ -0,0 +1,173 @@
+package catalog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var wantCatalog = Model{
+	"a1b2c3d4": {
+		Name: "Phones",
+		Devices: []DeviceModel{
+			{Model: "iPhone 7", OSVersion: "10.3"},
+			{Model: "iPhone SE", OSVersion: "11.0"},
+			{Model: "iPod touch (6th generation)", OSVersion: "10.0.2"},
+		},
+	},
+	"e5f6a7b8": {
+		Name: "Tablets",
+		Devices: []DeviceModel{
+			{Model: "iPad Air 2", OSVersion: "11.2"},
+			{Model: "Surface-like tablet", Family: "iPad"},
+		},
+	},
+}
+
+func TestOpen(t *testing.T) {
+	for _, fixture := range []string{"catalog.json", "catalog.yml"} {
+		t.Run(fixture, func(t *testing.T) {
+			catalog, err := Open(filepath.Join("testdata", fixture))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(catalog, wantCatalog) {
+				t.Errorf("got %+v, want %+v", catalog, wantCatalog)
+			}
+		})
+	}
+}
+
+func TestOpenError(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"invalid json", "catalog.json", `{"a1b2c3d4": `, "failed to parse device catalog as JSON"},
+		{"yaml in a json file", "catalog.json", "a1b2c3d4:\n  name: Phones\n", "failed to parse device catalog as JSON"},
+		{"invalid yaml", "catalog.yaml", "a1b2c3d4: [", "failed to parse device catalog as YAML"},
+		{"no devices", "catalog.yml", "a1b2c3d4:\n  name: Phones\n", "device set (a1b2c3d4) does not contain any device"},
+		{"no model", "catalog.yml", "a1b2c3d4:\n  devices:\n    - os_version: \"11.0\"\n", "device set (a1b2c3d4): device #1: model is not set"},
+		{"invalid family", "catalog.json", `{"a1b2c3d4": {"devices": [{"model": "Apple TV", "family": "tv"}]}}`, "device set (a1b2c3d4): device #1: invalid family (tv)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "catalog")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				if err := os.RemoveAll(dir); err != nil {
+					t.Error(err)
+				}
+			}()
+
+			pth := filepath.Join(dir, test.file)
+			if err := ioutil.WriteFile(pth, []byte(test.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := Open(pth); err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("error: got %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+
+	if _, err := Open(filepath.Join("testdata", "missing.json")); err == nil {
+		t.Errorf("expected error for a missing file")
+	}
+}
+
+func TestDeviceSet(t *testing.T) {
+	catalog, err := Open(filepath.Join("testdata", "catalog.yml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	deviceSet, ok := catalog.DeviceSet("e5f6a7b8")
+	if !ok || deviceSet.Name != "Tablets" {
+		t.Errorf("got (%+v, %t), want Tablets", deviceSet, ok)
+	}
+
+	if _, ok := catalog.DeviceSet("unknown"); ok {
+		t.Errorf("unknown device selection found")
+	}
+}
+
+func TestDeviceFamily(t *testing.T) {
+	tests := []struct {
+		device DeviceModel
+		want   string
+	}{
+		{DeviceModel{Model: "iPhone 7"}, FamilyIPhone},
+		{DeviceModel{Model: "iPod touch (6th generation)"}, FamilyIPhone},
+		{DeviceModel{Model: "iPad Pro (12.9-inch)"}, FamilyIPad},
+		{DeviceModel{Model: "Surface-like tablet", Family: "iPad"}, FamilyIPad},
+	}
+
+	for _, test := range tests {
+		if got := test.device.DeviceFamily(); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.device.Model, got, test.want)
+		}
+	}
+}
+
+func TestIncompatibilities(t *testing.T) {
+	deviceSet := DeviceSetModel{Devices: []DeviceModel{
+		{Model: "iPhone 7", OSVersion: "10.3"},
+		{Model: "iPhone SE", OSVersion: "11.0"},
+		{Model: "iPad Air 2", OSVersion: "11.2"},
+		{Model: "iPad mini"},
+	}}
+
+	tests := []struct {
+		name             string
+		minimumOSVersion string
+		deviceFamilies   []int64
+		want             []string
+	}{
+		{"compatible", "10.0", []int64{1, 2}, []string{}},
+		{"os version", "11", []int64{1, 2}, []string{"iPhone 7, iOS 10.3"}},
+		{"iphone only app runs on ipads", "10.0", []int64{1}, []string{}},
+		{"ipad only app", "10.0", []int64{2}, []string{"iPhone 7, iOS 10.3", "iPhone SE, iOS 11.0"}},
+		{"no device family", "", nil, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := []string{}
+			for _, incompatibility := range deviceSet.Incompatibilities(test.minimumOSVersion, test.deviceFamilies) {
+				got = append(got, incompatibility.Device.String())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"10.3", "10.3", 0},
+		{"10.3", "10.3.0", 0},
+		{"10.3.1", "10.3", 1},
+		{"9.3", "10.0", -1},
+		{"11", "10.3", 1},
+	}
+
+	for _, test := range tests {
+		if got := CompareVersions(test.a, test.b); got != test.want {
+			t.Errorf("CompareVersions(%q, %q): got %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package devices
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SelectionModel is a Test Cloud device selection with a label used in the logs and outputs.
+type SelectionModel struct {
+	Label string
+	ID    string
+}
+
+var labelPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// ParseSelections parses a comma or newline separated list of device selection ids,
+// every item is either a device selection id or a label=id pair.
+// Items without label are labelled with their id.
+// Labels have to be unique case insensitively, as they are used in the result file names.
+func ParseSelections(value string) ([]SelectionModel, error) {
+	items := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' })
+
+	selections := []SelectionModel{}
+	labels := map[string]string{} // Lowercased label - label map
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		selection := SelectionModel{Label: item, ID: item}
+		if split := strings.SplitN(item, "=", 2); len(split) == 2 {
+			selection = SelectionModel{Label: strings.TrimSpace(split[0]), ID: strings.TrimSpace(split[1])}
+		}
+
+		if selection.Label == "" || selection.ID == "" {
+			return nil, fmt.Errorf("invalid device selection: %s", item)
+		}
+		if !labelPattern.MatchString(selection.Label) {
+			return nil, fmt.Errorf("invalid device selection label (%s), only letters, digits, '_', '.' and '-' are allowed", selection.Label)
+		}
+		if label, ok := labels[strings.ToLower(selection.Label)]; ok {
+			if label == selection.Label {
+				return nil, fmt.Errorf("device selection (%s) is listed multiple times", selection.Label)
+			}
+			return nil, fmt.Errorf("device selection labels (%s) and (%s) differ only in case", label, selection.Label)
+		}
+		labels[strings.ToLower(selection.Label)] = selection.Label
+
+		selections = append(selections, selection)
+	}
+
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("no device selection found")
+	}
+
+	return selections, nil
+}
@@ -0,0 +1,90 @@
+package devices
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSelections(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []SelectionModel
+	}{
+		{
+			name:  "single id",
+			value: "a1b2c3d4",
+			want:  []SelectionModel{{Label: "a1b2c3d4", ID: "a1b2c3d4"}},
+		},
+		{
+			name:  "comma separated ids",
+			value: "a1b2c3d4, e5f6a7b8",
+			want:  []SelectionModel{{Label: "a1b2c3d4", ID: "a1b2c3d4"}, {Label: "e5f6a7b8", ID: "e5f6a7b8"}},
+		},
+		{
+			name:  "newline separated labelled ids",
+			value: "phones=a1b2c3d4\ntablets = e5f6a7b8\n",
+			want:  []SelectionModel{{Label: "phones", ID: "a1b2c3d4"}, {Label: "tablets", ID: "e5f6a7b8"}},
+		},
+		{
+			name:  "labelled and unlabelled ids",
+			value: "phones=a1b2c3d4,e5f6a7b8",
+			want:  []SelectionModel{{Label: "phones", ID: "a1b2c3d4"}, {Label: "e5f6a7b8", ID: "e5f6a7b8"}},
+		},
+		{
+			name:  "label characters",
+			value: "iOS_12.1-phones=a1b2c3d4",
+			want:  []SelectionModel{{Label: "iOS_12.1-phones", ID: "a1b2c3d4"}},
+		},
+		{
+			name:  "empty items",
+			value: "a1b2c3d4,,\n\n",
+			want:  []SelectionModel{{Label: "a1b2c3d4", ID: "a1b2c3d4"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseSelections(test.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSelectionsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"empty", "", "no device selection found"},
+		{"only separators", " , \n", "no device selection found"},
+		{"missing id", "phones=", "invalid device selection: phones="},
+		{"missing label", "=a1b2c3d4", "invalid device selection: =a1b2c3d4"},
+		{"label with space", "my phones=a1b2c3d4", "invalid device selection label (my phones)"},
+		{"label with slash", "phones/ios=a1b2c3d4", "invalid device selection label (phones/ios)"},
+		{"unlabelled id with invalid characters", "a1b2#c3d4", "invalid device selection label (a1b2#c3d4)"},
+		{"duplicated label", "phones=a1b2c3d4,phones=e5f6a7b8", "device selection (phones) is listed multiple times"},
+		{"duplicated id", "a1b2c3d4,a1b2c3d4", "device selection (a1b2c3d4) is listed multiple times"},
+		{"label colliding with unlabelled id", "a1b2c3d4,a1b2c3d4=e5f6a7b8", "device selection (a1b2c3d4) is listed multiple times"},
+		{"labels differing in case", "phones=a1b2c3d4,Phones=e5f6a7b8", "device selection labels (phones) and (Phones) differ only in case"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseSelections(test.value)
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got error %q, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
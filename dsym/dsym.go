@@ -0,0 +1,96 @@
+package dsym
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/machoutil"
+)
+
+// DWARFBinaryPth returns the path of the DWARF binary inside the .dSYM bundle.
+// If the bundle contains multiple binaries, the one named after the bundle is preferred.
+func DWARFBinaryPth(dsymPth string) (string, error) {
+	dwarfDir := filepath.Join(dsymPth, "Contents", "Resources", "DWARF")
+	pths, err := filepath.Glob(filepath.Join(dwarfDir, "*"))
+	if err != nil {
+		return "", err
+	}
+	if len(pths) == 0 {
+		return "", fmt.Errorf("no DWARF binary found in: %s", dwarfDir)
+	}
+
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(dsymPth), ".dSYM"), ".app")
+	for _, pth := range pths {
+		if filepath.Base(pth) == name {
+			return pth, nil
+		}
+	}
+	return pths[0], nil
+}
+
+// Open opens the DWARF binary of the .dSYM bundle.
+func Open(dsymPth string) (*machoutil.File, error) {
+	binaryPth, err := DWARFBinaryPth(dsymPth)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(binaryPth)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := machoutil.NewFile(file)
+	if err != nil {
+		if closeErr := file.Close(); closeErr != nil {
+			return nil, closeErr
+		}
+		return nil, err
+	}
+	binary.SetCloser(file)
+
+	return binary, nil
+}
+
+// UUIDs returns the LC_UUID of every architecture of the DWARF binary.
+func UUIDs(dsymPth string) (map[string]string, error) {
+	binary, err := Open(dsymPth)
+	if err != nil {
+		return nil, err
+	}
+
+	uuids := binary.UUIDs()
+	return uuids, binary.Close()
+}
+
+// Verify checks that every architecture of the app executable has a matching DWARF binary slice.
+func Verify(executableUUIDs, dsymUUIDs map[string]string) error {
+	if len(executableUUIDs) == 0 {
+		return fmt.Errorf("no LC_UUID found in the app executable")
+	}
+
+	archs := []string{}
+	for arch := range executableUUIDs {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+
+	mismatches := []string{}
+	for _, arch := range archs {
+		executableUUID := executableUUIDs[arch]
+		dsymUUID, ok := dsymUUIDs[arch]
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s (app) - missing (dSYM)", arch, executableUUID))
+		} else if dsymUUID != executableUUID {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s (app) - %s (dSYM)", arch, executableUUID, dsymUUID))
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("dSYM does not match the app executable:\n%s", strings.Join(mismatches, "\n"))
+	}
+	return nil
+}
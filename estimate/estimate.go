@@ -0,0 +1,178 @@
+package estimate
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/assembly"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/testfilter"
+)
+
+// DefaultTestDuration is used for the tests without history, if the history is empty.
+const DefaultTestDuration = time.Minute
+
+// HistoryModel holds the mean test case durations of previous test runs, by test method.
+type HistoryModel struct {
+	durations map[string]time.Duration
+	mean      time.Duration
+}
+
+// Model ...
+type Model struct {
+	TestCount int
+	// UnknownTestCount is the number of tests estimated without history.
+	UnknownTestCount int
+	DeviceCount      int
+	// Duration is the estimated test duration on a single device.
+	Duration time.Duration
+}
+
+// DeviceMinutes returns the estimated device minutes of the run on every device.
+func (estimate Model) DeviceMinutes() float64 {
+	return estimate.Duration.Minutes() * float64(estimate.DeviceCount)
+}
+
+// ReadHistory reads the test case durations of an NUnit 2 or NUnit 3 test result file.
+func ReadHistory(pth string) (HistoryModel, error) {
+	file, err := os.Open(pth)
+	if err != nil {
+		return HistoryModel{}, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close test result, error: %s", err)
+		}
+	}()
+
+	totals := map[string]time.Duration{}
+	counts := map[string]int{}
+	var total time.Duration
+	count := 0
+
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return HistoryModel{}, fmt.Errorf("failed to parse test result (%s), error: %s", pth, err)
+		}
+
+		element, ok := token.(xml.StartElement)
+		if !ok || element.Name.Local != "test-case" {
+			continue
+		}
+
+		name, duration, ok := testCaseDuration(element)
+		if !ok {
+			continue
+		}
+
+		totals[name] += duration
+		counts[name]++
+		total += duration
+		count++
+	}
+
+	history := HistoryModel{durations: map[string]time.Duration{}}
+	for name, duration := range totals {
+		history.durations[name] = duration / time.Duration(counts[name])
+	}
+	if count > 0 {
+		history.mean = total / time.Duration(count)
+	}
+	return history, nil
+}
+
+// testCaseDuration returns the test method name and duration of an executed test-case element,
+// NUnit 2 stores the duration in the time, NUnit 3 in the duration attribute, in seconds.
+func testCaseDuration(element xml.StartElement) (string, time.Duration, bool) {
+	attributes := map[string]string{}
+	for _, attribute := range element.Attr {
+		attributes[attribute.Name.Local] = attribute.Value
+	}
+
+	if strings.EqualFold(attributes["executed"], "false") {
+		return "", 0, false
+	}
+
+	name := attributes["fullname"]
+	if name == "" {
+		name = attributes["name"]
+	}
+	// parameterized test cases: Namespace.Fixture.Test(1,2)
+	if idx := strings.Index(name, "("); idx != -1 {
+		name = name[:idx]
+	}
+	if name == "" {
+		return "", 0, false
+	}
+
+	value := attributes["duration"]
+	if value == "" {
+		value = attributes["time"]
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", 0, false
+	}
+
+	return name, time.Duration(seconds * float64(time.Second)), true
+}
+
+// Duration returns the mean duration of the test method's cases.
+func (history HistoryModel) Duration(testName string) (time.Duration, bool) {
+	duration, ok := history.durations[testName]
+	return duration, ok
+}
+
+// fallbackDuration is used for the tests without history.
+func (history HistoryModel) fallbackDuration() time.Duration {
+	if history.mean > 0 {
+		return history.mean
+	}
+	return DefaultTestDuration
+}
+
+// Estimate estimates the duration of the tests selected by the filters on the given number of devices.
+func Estimate(inventory assembly.InventoryModel, filters []testfilter.Model, history HistoryModel, deviceCount int) Model {
+	estimate := Model{DeviceCount: deviceCount}
+	for _, fixture := range inventory.Fixtures {
+		for _, test := range fixture.Tests {
+			if !testfilter.Selects(filters, fixture, test) {
+				continue
+			}
+
+			count := test.Cases * fixture.Instances
+			duration, ok := history.Duration(fixture.FullName + "." + test.Name)
+			if !ok {
+				duration = history.fallbackDuration()
+				estimate.UnknownTestCount += count
+			}
+
+			estimate.TestCount += count
+			estimate.Duration += duration * time.Duration(count)
+		}
+	}
+	return estimate
+}
+
+// CheckBudget returns an error if the estimated device minutes exceed the budget,
+// or if the budget can not be verified, because the estimate does not include some of the test runs.
+func CheckBudget(deviceMinutes, budget float64, notEstimated []string) error {
+	formattedBudget := strconv.FormatFloat(budget, 'f', -1, 64)
+	if deviceMinutes > budget {
+		return fmt.Errorf("estimated device minutes (%s) exceed the budget (%s)", strconv.FormatFloat(deviceMinutes, 'f', 1, 64), formattedBudget)
+	}
+	if len(notEstimated) > 0 {
+		// the test runs without estimate may exceed the budget
+		return fmt.Errorf("the budget (%s) can not be verified, the estimate does not include: %s", formattedBudget, strings.Join(notEstimated, ", "))
+	}
+	return nil
+}
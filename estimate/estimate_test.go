@@ -0,0 +1,189 @@
+package estimate
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/assembly"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/testfilter"
+)
+
+var testInventory = assembly.InventoryModel{
+	Assembly: "Sample.UITests.dll",
+	Fixtures: []assembly.FixtureModel{
+		{
+			FullName:  "Sample.UITests.LoginTests",
+			Instances: 1,
+			Tests: []assembly.TestModel{
+				{Name: "Login", Cases: 1},
+				{Name: "LoginFails", Categories: []string{"Negative"}, Cases: 2},
+				{Name: "LoginTimeout", Cases: 1, Ignored: true},
+			},
+		},
+		{
+			FullName:  "Sample.UITests.SignupTests",
+			Instances: 1,
+			Tests:     []assembly.TestModel{{Name: "Signup", Cases: 1}},
+		},
+	},
+}
+
+func readHistory(t *testing.T, name string) HistoryModel {
+	history, err := ReadHistory(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read history: %s", err)
+	}
+	return history
+}
+
+func TestReadHistory(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		wantDurations map[string]time.Duration
+		wantMean      time.Duration
+	}{
+		{
+			fixture: "TestResult.nunit2.xml",
+			wantDurations: map[string]time.Duration{
+				"Sample.UITests.LoginTests.Login":      40 * time.Second,
+				"Sample.UITests.LoginTests.LoginFails": 50 * time.Second,
+			},
+			wantMean: 45 * time.Second,
+		},
+		{
+			fixture: "TestResult.nunit3.xml",
+			wantDurations: map[string]time.Duration{
+				"Sample.UITests.CheckoutTests.Pay":    45500 * time.Millisecond,
+				"Sample.UITests.CheckoutTests.Refund": 37250 * time.Millisecond,
+			},
+			wantMean: 40 * time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			history := readHistory(t, test.fixture)
+			if !reflect.DeepEqual(history.durations, test.wantDurations) {
+				t.Errorf("durations: got %v, want %v", history.durations, test.wantDurations)
+			}
+			if history.mean != test.wantMean {
+				t.Errorf("mean: got %s, want %s", history.mean, test.wantMean)
+			}
+		})
+	}
+}
+
+func TestReadHistoryError(t *testing.T) {
+	if _, err := ReadHistory(filepath.Join("testdata", "missing.xml")); err == nil {
+		t.Errorf("expected error for a missing file")
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name        string
+		filters     []testfilter.Model
+		history     string
+		deviceCount int
+		want        Model
+	}{
+		{
+			name:        "history, single device",
+			history:     "TestResult.nunit2.xml",
+			deviceCount: 1,
+			// Login: 40s, LoginFails: 2 * 50s, Signup: mean 45s
+			want: Model{TestCount: 4, UnknownTestCount: 1, DeviceCount: 1, Duration: 185 * time.Second},
+		},
+		{
+			name:        "history, several devices",
+			history:     "TestResult.nunit2.xml",
+			deviceCount: 3,
+			want:        Model{TestCount: 4, UnknownTestCount: 1, DeviceCount: 3, Duration: 185 * time.Second},
+		},
+		{
+			name:        "filtered",
+			filters:     []testfilter.Model{{Kind: testfilter.KindCategory, Value: "Negative"}},
+			history:     "TestResult.nunit2.xml",
+			deviceCount: 2,
+			want:        Model{TestCount: 2, UnknownTestCount: 0, DeviceCount: 2, Duration: 100 * time.Second},
+		},
+		{
+			name:        "no history",
+			deviceCount: 2,
+			want:        Model{TestCount: 4, UnknownTestCount: 4, DeviceCount: 2, Duration: 4 * DefaultTestDuration},
+		},
+		{
+			name:        "history of other tests",
+			history:     "TestResult.nunit3.xml",
+			deviceCount: 1,
+			want:        Model{TestCount: 4, UnknownTestCount: 4, DeviceCount: 1, Duration: 4 * 40 * time.Second},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			history := HistoryModel{}
+			if test.history != "" {
+				history = readHistory(t, test.history)
+			}
+
+			if got := Estimate(testInventory, test.filters, history, test.deviceCount); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDeviceMinutes(t *testing.T) {
+	estimate := Model{DeviceCount: 3, Duration: 90 * time.Second}
+	if got := estimate.DeviceMinutes(); got != 4.5 {
+		t.Errorf("got %v, want 4.5", got)
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	// the test runs of every device set and locale, without history every test takes a minute
+	deviceSets := map[string]int{"iphones": 3, "ipads": 2}
+	locales := []string{"en_US", "de_DE"}
+
+	deviceMinutes := 0.0
+	for _, deviceCount := range deviceSets {
+		for range locales {
+			deviceMinutes += Estimate(testInventory, nil, HistoryModel{}, deviceCount).DeviceMinutes()
+		}
+	}
+	if deviceMinutes != 40 {
+		t.Fatalf("device minutes: got %v, want 40", deviceMinutes)
+	}
+
+	tests := []struct {
+		name         string
+		budget       float64
+		notEstimated []string
+		wantErr      string
+	}{
+		{"within the budget", 60, nil, ""},
+		{"at the budget", 40, nil, ""},
+		{"over the budget", 39.9, nil, "estimated device minutes (40.0) exceed the budget (39.9)"},
+		{"over the budget with runs not estimated", 30, []string{"Admin.UITests -> Admin.iOS"}, "estimated device minutes (40.0) exceed the budget (30)"},
+		{"runs not estimated", 60, []string{"Admin.UITests -> Admin.iOS (tablets)"}, "the budget (60) can not be verified, the estimate does not include: Admin.UITests -> Admin.iOS (tablets)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckBudget(deviceMinutes, test.budget, test.notEstimated)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("error: got %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
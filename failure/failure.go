@@ -0,0 +1,48 @@
+package failure
+
+import "github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/hints"
+
+// Reason classifies why the step failed.
+type Reason string
+
+const (
+	// ReasonInputError ...
+	ReasonInputError Reason = "input-error"
+	// ReasonBuildFailure ...
+	ReasonBuildFailure Reason = "build-failure"
+	// ReasonArtifactNotFound ...
+	ReasonArtifactNotFound Reason = "artifact-not-found"
+	// ReasonToolNotFound ...
+	ReasonToolNotFound Reason = "tool-not-found"
+	// ReasonSubmissionError means the test run could not be submitted or completed by Test Cloud,
+	// it is worth retrying.
+	ReasonSubmissionError Reason = "submission-error"
+	// ReasonTestFailures means the tests ran and some of them failed.
+	ReasonTestFailures Reason = "test-failures"
+)
+
+// Combine returns the reason of multiple failed test runs,
+// test failures take precedence, so they are not hidden by automatic retries.
+func Combine(reasons []Reason) Reason {
+	combined := Reason("")
+	for _, reason := range reasons {
+		if reason == ReasonTestFailures {
+			return reason
+		}
+		if combined == "" {
+			combined = reason
+		}
+	}
+	return combined
+}
+
+// Categorize classifies a failed submission by the known Test Cloud errors of its messages,
+// errors which do not go away by submitting again are input errors.
+func Categorize(messages []string) Reason {
+	for _, hint := range hints.Collect(messages) {
+		if !hint.Retryable {
+			return ReasonInputError
+		}
+	}
+	return ReasonSubmissionError
+}
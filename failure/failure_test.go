@@ -0,0 +1,48 @@
+package failure
+
+import "testing"
+
+func TestCombine(t *testing.T) {
+	tests := []struct {
+		name    string
+		reasons []Reason
+		want    Reason
+	}{
+		{"no reasons", nil, ""},
+		{"single reason", []Reason{ReasonArtifactNotFound}, ReasonArtifactNotFound},
+		{"first reason wins", []Reason{ReasonBuildFailure, ReasonSubmissionError, ReasonInputError}, ReasonBuildFailure},
+		{"test failures take precedence", []Reason{ReasonSubmissionError, ReasonTestFailures, ReasonInputError}, ReasonTestFailures},
+		{"test failures last", []Reason{ReasonArtifactNotFound, ReasonBuildFailure, ReasonTestFailures}, ReasonTestFailures},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Combine(test.reasons); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCategorize(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		want     Reason
+	}{
+		{"no messages", nil, ReasonSubmissionError},
+		{"unknown error", []string{"The operation has timed out."}, ReasonSubmissionError},
+		{"invalid api key", []string{"Error: Invalid API key."}, ReasonInputError},
+		{"unknown device selection", []string{"Unknown device selection (9f2b4c1a)"}, ReasonInputError},
+		{"quota exceeded", []string{"You have exceeded your device hours quota for this month"}, ReasonSubmissionError},
+		{"input error among retryable errors", []string{"Concurrency limit reached", "The dSYM does not match the app binary (UUID mismatch)"}, ReasonInputError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Categorize(test.messages); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package hints
+
+import (
+	"regexp"
+)
+
+// HintModel explains a known Test Cloud error and how to fix it.
+type HintModel struct {
+	Title       string
+	Explanation string
+	Steps       []string
+	// Retryable is true if the error may go away by submitting again, without changing the inputs
+	Retryable bool
+}
+
+type patternModel struct {
+	re   *regexp.Regexp
+	hint HintModel
+}
+
+var patterns = []patternModel{
+	{
+		re: regexp.MustCompile(`(?i)((invalid|incorrect|unknown|wrong) api[ _-]?key\b|\bapi[ _-]?key\b.*\b(invalid|not valid|not found)\b|\b401\b.*\bunauthori[sz]ed\b|\bunauthori[sz]ed\b.*\b401\b|^(error: )?unauthori[sz]ed\.?$)`),
+		hint: HintModel{
+			Title:       "Invalid Test Cloud API key",
+			Explanation: "Test Cloud rejected the API key of the submission.",
+			Steps: []string{
+				"Check that the test_cloud_api_key secret holds the API key of the team shown on Test Cloud's Teams & Apps page",
+				"Check that xamarin_user is a member of the API key's team",
+				"If the API key was regenerated, update the secret",
+			},
+		},
+	},
+	{
+		re: regexp.MustCompile(`(?i)((unknown|invalid|no such) device (selection|set)\b|device (selection|set) ("[^"]*"|'[^']*'|\(?[\w-]+\)?) (was )?(not found|does not exist|is invalid))`),
+		hint: HintModel{
+			Title:       "Unknown device selection",
+			Explanation: "Test Cloud does not know the device selection id of the submission.",
+			Steps: []string{
+				"Copy the device selection id from the last step of Test Cloud's new test run wizard (the value of --devices)",
+				"Check test_cloud_devices, the devices of test_project_mapping and the devices of test_cloud_device_rules",
+				"Device selections belong to a team, check that the API key belongs to the same team",
+			},
+		},
+	},
+	{
+		re: regexp.MustCompile(`(?i)((not (seem to be )?linked with|does not contain|missing|without)( the)? (calabash|test ?cloud agent|xamarin\.testcloud\.agent)|(calabash|test ?cloud agent)( server)? (was |is )?(not found|missing|not linked))`),
+		hint: HintModel{
+			Title:       "App is not linked with the Test Cloud agent",
+			Explanation: "Test Cloud can only run UITests on iOS apps which start the Xamarin Test Cloud agent.",
+			Steps: []string{
+				"Add the Xamarin.TestCloud.Agent NuGet package to the iOS app project",
+				"Call Xamarin.Calabash.Start() in AppDelegate.FinishedLaunching, inside #if ENABLE_TEST_CLOUD",
+				"Define ENABLE_TEST_CLOUD in the build configuration selected by xamarin_configuration",
+			},
+		},
+	},
+	{
+		re: regexp.MustCompile(`(?i)((version of )?xamarin\.uitest.*(does not match|mismatch|not supported|no longer supported|too old|outdated)|(unsupported|outdated|incompatible) (version of )?xamarin\.uitest)`),
+		hint: HintModel{
+			Title:       "Xamarin.UITest version mismatch",
+			Explanation: "The Xamarin.UITest version of the tests, of test-cloud.exe or of Test Cloud do not match.",
+			Steps: []string{
+				"Update the Xamarin.UITest NuGet package of every UITest project to the same, supported version",
+				"Restore the NuGet packages, so packages/Xamarin.UITest.<version>/tools/test-cloud.exe is the same version",
+				"Remove the older Xamarin.UITest package directories from the packages directory",
+			},
+		},
+	},
+	{
+		re: regexp.MustCompile(`(?i)(\bdsym\b.*\b(invalid|not valid|does not match|mismatch|could not be (read|parsed|processed)|corrupt)|(invalid|corrupt) dsym\b)`),
+		hint: HintModel{
+			Title:       "Invalid dSYM",
+			Explanation: "Test Cloud could not use the dSYM of the submission to symbolicate crashes.",
+			Steps: []string{
+				"Submit the dSYM generated by the same build as the ipa, a clean build helps",
+				"Set dsym_mismatch to fail, to catch the mismatch before submitting",
+				"Check that the build configuration generates a dSYM (MtouchDebug or debug symbols enabled)",
+			},
+		},
+	},
+	{
+		re: regexp.MustCompile(`(?i)((quota|device (hours|minutes)|concurrency|concurrent (test )?runs|subscription|plan)( limit)? (has been |has |was |is )?(exceeded|reached|expired|exhausted)|exceeded (the |your |its )?(\w+ )?(quota|device (hours|minutes)|concurrency limit|plan limit))`),
+		hint: HintModel{
+			Title:       "Test Cloud quota exceeded",
+			Explanation: "The team has run out of device time or concurrent test runs of its subscription.",
+			Steps: []string{
+				"Wait for the running test runs of the team to finish and retry",
+				"Reduce the number of devices, locales or test runs, device_minutes_budget helps to catch expensive runs",
+				"Check the subscription of the team on Test Cloud's account page",
+			},
+			Retryable: true,
+		},
+	},
+}
+
+// Match returns the hint of the first known error pattern matching the message.
+func Match(message string) (HintModel, bool) {
+	for _, pattern := range patterns {
+		if pattern.re.MatchString(message) {
+			return pattern.hint, true
+		}
+	}
+	return HintModel{}, false
+}
+
+// Collect returns the hints of the messages, every hint once, in the order of the messages.
+func Collect(messages []string) []HintModel {
+	seen := map[string]bool{}
+	hints := []HintModel{}
+	for _, message := range messages {
+		hint, ok := Match(message)
+		if !ok || seen[hint.Title] {
+			continue
+		}
+		seen[hint.Title] = true
+		hints = append(hints, hint)
+	}
+	return hints
+}
@@ -0,0 +1,104 @@
+package hints
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	titleAPIKey          = "Invalid Test Cloud API key"
+	titleDeviceSelection = "Unknown device selection"
+	titleAgent           = "App is not linked with the Test Cloud agent"
+	titleUITestVersion   = "Xamarin.UITest version mismatch"
+	titleDSYM            = "Invalid dSYM"
+	titleQuota           = "Test Cloud quota exceeded"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		message string
+		title   string
+	}{
+		// test-cloud.exe output
+		{"Error: Invalid API key.", titleAPIKey},
+		{"The API key 0123456789abcdef is not valid for user ci@example.com", titleAPIKey},
+		{"Unauthorized", titleAPIKey},
+		{"The remote server returned an error: (401) Unauthorized.", titleAPIKey},
+		{"Error: No such device selection: 9f2b4c1a", titleDeviceSelection},
+		{"The .ipa file does not seem to be linked with Calabash.", titleAgent},
+		{"The app is missing the Xamarin.TestCloud.Agent, call Xamarin.Calabash.Start()", titleAgent},
+		{"Calabash server was not found in the app bundle", titleAgent},
+		{"The version of Xamarin.UITest used by the tests (2.0.5) does not match the version of test-cloud.exe (2.0.10)", titleUITestVersion},
+		{"This version of Xamarin.UITest is no longer supported, please update to the latest version", titleUITestVersion},
+		{"The dSYM does not match the app binary (UUID mismatch)", titleDSYM},
+		{"Invalid dSYM, symbols will not be available", titleDSYM},
+		{"You have exceeded your device hours quota for this month", titleQuota},
+		{"Concurrency limit reached, wait for your running test runs to finish", titleQuota},
+		// async json ErrorMessages
+		{"Unknown device selection (9f2b4c1a)", titleDeviceSelection},
+		{"Device set '9f2b4c1a' does not exist", titleDeviceSelection},
+		{"Your subscription has expired", titleQuota},
+		{"Plan limit exceeded: 2 concurrent test runs", titleQuota},
+	}
+
+	for _, test := range tests {
+		hint, ok := Match(test.message)
+		if !ok {
+			t.Errorf("%q: no hint, want %q", test.message, test.title)
+			continue
+		}
+		if hint.Title != test.title {
+			t.Errorf("%q: got %q, want %q", test.message, hint.Title, test.title)
+		}
+	}
+}
+
+func TestMatchNegative(t *testing.T) {
+	for _, message := range []string{
+		"",
+		"Uploading 42%",
+		"Tests enqueued",
+		"Using Xamarin.UITest 2.2.0",
+		// test output, which mentions the keywords of the patterns
+		"Test failed: expected the 'Unauthorized' banner to be hidden",
+		"LoginTests.ShowsUnauthorizedError: Passed",
+		"ApiKeyIsInvalidWhenEmpty: Passed",
+		"Test plan: checking the character limit of the name field",
+		"Wait time exceeded the limit of 30 seconds",
+		"Device set up complete, element not found: LoginButton",
+		"Running dsymutil on Sample.app",
+		"Calabash.Start() called, waiting for the server",
+	} {
+		if hint, ok := Match(message); ok {
+			t.Errorf("%q: unexpected hint: %s", message, hint.Title)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	messages := []string{
+		"Uploading 100%",
+		"Error: Invalid API key.",
+		"You have exceeded your device hours quota for this month",
+		"The remote server returned an error: (401) Unauthorized.",
+	}
+
+	titles := []string{}
+	for _, hint := range Collect(messages) {
+		titles = append(titles, hint.Title)
+	}
+
+	want := []string{titleAPIKey, titleQuota}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("got %v, want %v", titles, want)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	for _, pattern := range patterns {
+		want := pattern.hint.Title == titleQuota
+		if pattern.hint.Retryable != want {
+			t.Errorf("%s: got retryable %v, want %v", pattern.hint.Title, pattern.hint.Retryable, want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package ipa
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/plist"
+)
+
+// Reader ...
+type Reader struct {
+	zipReader *zip.ReadCloser
+	appDir    string // Payload/<name>.app/
+}
+
+// Open ...
+func Open(pth string) (*Reader, error) {
+	zipReader, err := zip.OpenReader(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ipa (%s), error: %s", pth, err)
+	}
+
+	appDir := ""
+	for _, file := range zipReader.File {
+		parts := strings.Split(file.Name, "/")
+		if len(parts) > 2 && parts[0] == "Payload" && strings.HasSuffix(parts[1], ".app") {
+			appDir = parts[0] + "/" + parts[1] + "/"
+			break
+		}
+	}
+
+	if appDir == "" {
+		if err := zipReader.Close(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no Payload/*.app found in ipa: %s", pth)
+	}
+
+	return &Reader{zipReader: zipReader, appDir: appDir}, nil
+}
+
+// Close ...
+func (reader *Reader) Close() error {
+	return reader.zipReader.Close()
+}
+
+// AppName returns the name of the app bundle, without the .app extension.
+func (reader *Reader) AppName() string {
+	return strings.TrimSuffix(path.Base(reader.appDir), ".app")
+}
+
+func (reader *Reader) file(relPth string) *zip.File {
+	name := reader.appDir + relPth
+	for _, file := range reader.zipReader.File {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
+}
+
+// HasFile reports whether the app bundle contains the file.
+func (reader *Reader) HasFile(relPth string) bool {
+	return reader.file(relPth) != nil
+}
+
+// ReadFile reads a file relative to the app bundle.
+func (reader *Reader) ReadFile(relPth string) ([]byte, error) {
+	file := reader.file(relPth)
+	if file == nil {
+		return nil, fmt.Errorf("%s not found in ipa", reader.appDir+relPth)
+	}
+
+	fileReader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	content, readErr := ioutil.ReadAll(fileReader)
+	if err := fileReader.Close(); err != nil && readErr == nil {
+		readErr = err
+	}
+	return content, readErr
+}
+
+// InfoPlist ...
+func (reader *Reader) InfoPlist() (plist.Data, error) {
+	content, err := reader.ReadFile("Info.plist")
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := plist.NewDataFromContent(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Info.plist, error: %s", err)
+	}
+	return data, nil
+}
+
+// ExecutableName returns the CFBundleExecutable of the app,
+// falling back to the bundle name if the Info.plist can not be read.
+func (reader *Reader) ExecutableName() string {
+	if infoPlist, err := reader.InfoPlist(); err == nil {
+		if name, ok := infoPlist.GetString("CFBundleExecutable"); ok && name != "" {
+			return name
+		}
+	}
+	return reader.AppName()
+}
+
+// Executable reads the main executable of the app.
+func (reader *Reader) Executable() ([]byte, error) {
+	return reader.ReadFile(reader.ExecutableName())
+}
+
+const embeddedProfileName = "embedded.mobileprovision"
+
+// EmbeddedProfile reads the embedded.mobileprovision of the app.
+func (reader *Reader) EmbeddedProfile() ([]byte, error) {
+	return reader.ReadFile(embeddedProfileName)
+}
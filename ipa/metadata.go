@@ -0,0 +1,48 @@
+package ipa
+
+// MetadataModel ...
+type MetadataModel struct {
+	BundleID         string
+	DisplayName      string
+	ShortVersion     string
+	BuildNumber      string
+	MinimumOSVersion string
+	DeviceFamilies   []int64
+}
+
+// Metadata reads the app metadata from the Info.plist of the app.
+func (reader *Reader) Metadata() (MetadataModel, error) {
+	infoPlist, err := reader.InfoPlist()
+	if err != nil {
+		return MetadataModel{}, err
+	}
+
+	metadata := MetadataModel{}
+	metadata.BundleID, _ = infoPlist.GetString("CFBundleIdentifier")
+	metadata.ShortVersion, _ = infoPlist.GetString("CFBundleShortVersionString")
+	metadata.BuildNumber, _ = infoPlist.GetString("CFBundleVersion")
+	metadata.MinimumOSVersion, _ = infoPlist.GetString("MinimumOSVersion")
+	metadata.DeviceFamilies, _ = infoPlist.GetIntArray("UIDeviceFamily")
+
+	if displayName, ok := infoPlist.GetString("CFBundleDisplayName"); ok && displayName != "" {
+		metadata.DisplayName = displayName
+	} else {
+		metadata.DisplayName, _ = infoPlist.GetString("CFBundleName")
+	}
+
+	return metadata, nil
+}
+
+// ReadMetadata ...
+func ReadMetadata(pth string) (MetadataModel, error) {
+	reader, err := Open(pth)
+	if err != nil {
+		return MetadataModel{}, err
+	}
+
+	metadata, err := reader.Metadata()
+	if closeErr := reader.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return metadata, err
+}
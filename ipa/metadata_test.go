@@ -0,0 +1,179 @@
+package ipa
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// tempDir creates a temporary dir, the returned func removes it.
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "ipa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// createIPA writes an ipa with the given files of the Sample.app bundle into a temporary dir,
+// the returned func removes the dir.
+func createIPA(t *testing.T, files map[string][]byte) (string, func()) {
+	dir, cleanup := tempDir(t)
+	pth := filepath.Join(dir, "Sample.ipa")
+	file, err := os.Create(pth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer := zip.NewWriter(file)
+	for name, content := range files {
+		fileWriter, err := writer.Create("Payload/Sample.app/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fileWriter.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return pth, cleanup
+}
+
+func readInfoPlistFixture(t *testing.T, name string) []byte {
+	content, err := ioutil.ReadFile(filepath.Join("..", "plist", "testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %s", err)
+	}
+	return content
+}
+
+func TestReadMetadata(t *testing.T) {
+	want := MetadataModel{
+		BundleID:         "com.example.testcloud",
+		DisplayName:      "Test Cloud Sample",
+		ShortVersion:     "1.2.3",
+		BuildNumber:      "42",
+		MinimumOSVersion: "10.0",
+		DeviceFamilies:   []int64{1, 2},
+	}
+
+	for _, fixture := range []string{"Info.xml.plist", "Info.binary.plist"} {
+		t.Run(fixture, func(t *testing.T) {
+			pth, cleanup := createIPA(t, map[string][]byte{"Info.plist": readInfoPlistFixture(t, fixture)})
+			defer cleanup()
+
+			metadata, err := ReadMetadata(pth)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(metadata, want) {
+				t.Errorf("got %+v, want %+v", metadata, want)
+			}
+		})
+	}
+}
+
+func TestReaderMetadataDisplayNameFallback(t *testing.T) {
+	infoPlist := `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0">
+<dict>
+	<key>CFBundleIdentifier</key>
+	<string>com.example.testcloud</string>
+	<key>CFBundleName</key>
+	<string>Sample</string>
+</dict>
+</plist>`
+	pth, cleanup := createIPA(t, map[string][]byte{"Info.plist": []byte(infoPlist)})
+	defer cleanup()
+
+	reader, err := Open(pth)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	metadata, err := reader.Metadata()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if metadata.DisplayName != "Sample" {
+		t.Errorf("display name: got %q, want %q", metadata.DisplayName, "Sample")
+	}
+	if metadata.DeviceFamilies != nil {
+		t.Errorf("device families: got %v, want none", metadata.DeviceFamilies)
+	}
+}
+
+func TestReadMetadataErrors(t *testing.T) {
+	binaryInfoPlist := readInfoPlistFixture(t, "Info.binary.plist")
+	xmlInfoPlist := readInfoPlistFixture(t, "Info.xml.plist")
+
+	tests := []struct {
+		name  string
+		files map[string][]byte
+	}{
+		{"missing Info.plist", map[string][]byte{"Sample": []byte("executable")}},
+		{"truncated binary Info.plist", map[string][]byte{"Info.plist": binaryInfoPlist[:len(binaryInfoPlist)/2]}},
+		{"truncated xml Info.plist", map[string][]byte{"Info.plist": xmlInfoPlist[:len(xmlInfoPlist)/2]}},
+		{"corrupt Info.plist", map[string][]byte{"Info.plist": []byte("not a property list")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pth, cleanup := createIPA(t, test.files)
+			defer cleanup()
+
+			if _, err := ReadMetadata(pth); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	notZipPth := filepath.Join(dir, "NotZip.ipa")
+	if err := ioutil.WriteFile(notZipPth, []byte("not a zip"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	noPayloadPth := filepath.Join(dir, "NoPayload.ipa")
+	file, err := os.Create(noPayloadPth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(file)
+	if _, err := writer.Create("Sample.app/Info.plist"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pth := range []string{filepath.Join(dir, "Missing.ipa"), notZipPth, noPayloadPth} {
+		if _, err := Open(pth); err == nil {
+			t.Errorf("%s: expected error", filepath.Base(pth))
+		}
+	}
+}
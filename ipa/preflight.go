@@ -0,0 +1,102 @@
+package ipa
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/machoutil"
+)
+
+// Symbols and Objective-C class names of the Calabash server,
+// linked into the app by Xamarin.Calabash.Start().
+var testCloudAgentMarkers = []string{
+	"CalabashServer",
+	"LPHTTPServer",
+	"LPRouter",
+}
+
+// App is the app executable and the embedded provisioning profile of an ipa,
+// read into memory once for the checks before the submission.
+type App struct {
+	ExecutableName string
+	Executable     []byte
+	Binary         *machoutil.File
+	// EmbeddedProfile is nil if the app has no embedded.mobileprovision.
+	EmbeddedProfile []byte
+}
+
+// ReadApp reads the app executable and the embedded provisioning profile of the ipa.
+func ReadApp(pth string) (*App, error) {
+	reader, err := Open(pth)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Warnf("Failed to close ipa, error: %s", err)
+		}
+	}()
+
+	app := &App{ExecutableName: reader.ExecutableName()}
+
+	app.Executable, err = reader.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read app executable, error: %s", err)
+	}
+
+	if reader.HasFile(embeddedProfileName) {
+		app.EmbeddedProfile, err = reader.EmbeddedProfile()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedded provisioning profile, error: %s", err)
+		}
+	}
+
+	app.Binary, err = machoutil.NewFileFromContent(app.Executable)
+	if err != nil {
+		return nil, fmt.Errorf("app executable (%s) is not a valid Mach-O binary: %s", app.ExecutableName, err)
+	}
+
+	return app, nil
+}
+
+// PreflightModel ...
+type PreflightModel struct {
+	Executable string
+	Archs      []string
+}
+
+// Preflight checks that the app is a device build with the Test Cloud agent linked and a provisioning profile embedded.
+func Preflight(app *App) (PreflightModel, error) {
+	model := PreflightModel{Executable: app.ExecutableName, Archs: app.Binary.Archs()}
+
+	simulatorSlices := []string{}
+	for _, slice := range app.Binary.Slices {
+		if slice.IsSimulator() {
+			simulatorSlices = append(simulatorSlices, slice.Arch)
+		}
+	}
+	if len(simulatorSlices) == len(app.Binary.Slices) {
+		return model, fmt.Errorf("app executable (%s) is built only for the iOS Simulator (%s), build the app for the iPhone platform", model.Executable, strings.Join(simulatorSlices, ", "))
+	}
+
+	if !app.Binary.HasSymbolContaining(testCloudAgentMarkers...) && !containsAny(app.Executable, testCloudAgentMarkers...) {
+		return model, fmt.Errorf("Test Cloud agent not found in app executable (%s), call Xamarin.Calabash.Start() in AppDelegate.FinishedLaunching and build with the ENABLE_TEST_CLOUD symbol defined", model.Executable)
+	}
+
+	if app.EmbeddedProfile == nil {
+		return model, fmt.Errorf("no %s found in the app, sign the app with a development, ad-hoc or enterprise provisioning profile", embeddedProfileName)
+	}
+
+	return model, nil
+}
+
+func containsAny(content []byte, substrs ...string) bool {
+	for _, substr := range substrs {
+		if bytes.Contains(content, []byte(substr)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package ipa
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readExecutableFixture(t *testing.T, name string) []byte {
+	content, err := ioutil.ReadFile(filepath.Join("..", "machoutil", "testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %s", err)
+	}
+	return content
+}
+
+func TestPreflight(t *testing.T) {
+	profile := []byte("profile")
+
+	tests := []struct {
+		name       string
+		executable string
+		profile    []byte
+		wantArchs  []string
+		wantErr    string
+	}{
+		{"device build", "device_arm64", profile, []string{"arm64"}, ""},
+		{"universal device build", "universal_armv7_arm64", profile, []string{"armv7", "arm64"}, ""},
+		{"arm64 simulator slice", "simulator_arm64", profile, []string{"arm64"}, "is built only for the iOS Simulator (arm64)"},
+		{"x86_64 simulator slice", "simulator_x86_64_version_min", profile, []string{"x86_64"}, "is built only for the iOS Simulator (x86_64)"},
+		{"missing agent", "no_agent_arm64", profile, []string{"arm64"}, "Test Cloud agent not found"},
+		{"missing profile", "device_arm64", nil, []string{"arm64"}, "no embedded.mobileprovision found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files := map[string][]byte{"Sample": readExecutableFixture(t, test.executable)}
+			if test.profile != nil {
+				files["embedded.mobileprovision"] = test.profile
+			}
+
+			pth, cleanup := createIPA(t, files)
+			defer cleanup()
+
+			app, err := ReadApp(pth)
+			if err != nil {
+				t.Fatalf("failed to read app: %s", err)
+			}
+
+			preflight, err := Preflight(app)
+			if test.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+				t.Fatalf("error: got %v, want %q", err, test.wantErr)
+			}
+
+			if preflight.Executable != "Sample" {
+				t.Errorf("executable: got %q, want %q", preflight.Executable, "Sample")
+			}
+			if strings.Join(preflight.Archs, ",") != strings.Join(test.wantArchs, ",") {
+				t.Errorf("archs: got %v, want %v", preflight.Archs, test.wantArchs)
+			}
+		})
+	}
+}
+
+func TestReadAppInvalidExecutable(t *testing.T) {
+	pth, cleanup := createIPA(t, map[string][]byte{"Sample": []byte("#!/bin/sh")})
+	defer cleanup()
+
+	if _, err := ReadApp(pth); err == nil || !strings.Contains(err.Error(), "is not a valid Mach-O binary") {
+		t.Errorf("error: got %v, want invalid Mach-O binary", err)
+	}
+}
@@ -0,0 +1,221 @@
+package machoutil
+
+import (
+	"bytes"
+	"debug/macho"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Slice is a single architecture of a (possibly universal) Mach-O binary.
+type Slice struct {
+	Arch string
+	File *macho.File
+}
+
+// File ...
+type File struct {
+	Slices []Slice
+
+	fat    *macho.FatFile
+	closer io.Closer
+}
+
+// NewFile opens a thin or a universal (fat) Mach-O binary.
+func NewFile(reader io.ReaderAt) (*File, error) {
+	fat, err := macho.NewFatFile(reader)
+	if err == nil {
+		file := &File{fat: fat}
+		for _, arch := range fat.Arches {
+			file.Slices = append(file.Slices, Slice{Arch: ArchName(arch.Cpu, arch.SubCpu), File: arch.File})
+		}
+		return file, nil
+	} else if err != macho.ErrNotFat {
+		return nil, fmt.Errorf("failed to parse universal binary, error: %s", err)
+	}
+
+	thin, err := macho.NewFile(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Mach-O binary, error: %s", err)
+	}
+
+	return &File{Slices: []Slice{{Arch: ArchName(thin.Cpu, thin.SubCpu), File: thin}}}, nil
+}
+
+// NewFileFromContent ...
+func NewFileFromContent(content []byte) (*File, error) {
+	return NewFile(bytes.NewReader(content))
+}
+
+// SetCloser sets the underlying reader to close, when the File is closed.
+func (file *File) SetCloser(closer io.Closer) {
+	file.closer = closer
+}
+
+// Close ...
+func (file *File) Close() error {
+	var closeErr error
+	if file.fat != nil {
+		closeErr = file.fat.Close()
+	} else {
+		for _, slice := range file.Slices {
+			if err := slice.File.Close(); err != nil {
+				closeErr = err
+			}
+		}
+	}
+
+	if file.closer != nil {
+		if err := file.closer.Close(); err != nil {
+			closeErr = err
+		}
+	}
+	return closeErr
+}
+
+// Archs ...
+func (file *File) Archs() []string {
+	archs := []string{}
+	for _, slice := range file.Slices {
+		archs = append(archs, slice.Arch)
+	}
+	return archs
+}
+
+// ArchName returns the name Xcode uses for the given cpu type and subtype.
+func ArchName(cpu macho.Cpu, subCpu uint32) string {
+	// the upper byte of the subtype holds capability bits
+	subCpu &= 0x00ffffff
+
+	switch cpu {
+	case macho.Cpu386:
+		return "i386"
+	case macho.CpuAmd64:
+		return "x86_64"
+	case macho.CpuArm:
+		switch subCpu {
+		case 6:
+			return "armv6"
+		case 9:
+			return "armv7"
+		case 11:
+			return "armv7s"
+		case 12:
+			return "armv7k"
+		}
+		return "arm"
+	case macho.CpuArm64:
+		if subCpu == 2 {
+			return "arm64e"
+		}
+		return "arm64"
+	}
+	return strings.ToLower(cpu.String())
+}
+
+// Platform is the platform of a Mach-O slice, as defined in the LC_BUILD_VERSION load command.
+type Platform uint32
+
+// Platform ...
+const (
+	PlatformUnknown      Platform = 0
+	PlatformIOS          Platform = 2
+	PlatformIOSSimulator Platform = 7
+)
+
+// String ...
+func (platform Platform) String() string {
+	switch platform {
+	case PlatformIOS:
+		return "iOS"
+	case PlatformIOSSimulator:
+		return "iOS Simulator"
+	}
+	return fmt.Sprintf("platform %d", uint32(platform))
+}
+
+const (
+	loadCmdUUID               = 0x1b
+	loadCmdVersionMinIPhoneOS = 0x25
+	loadCmdBuildVersion       = 0x32
+)
+
+// Platform returns the platform the slice is built for,
+// read from the LC_BUILD_VERSION load command.
+// Binaries built before LC_BUILD_VERSION have an LC_VERSION_MIN_IPHONEOS load command
+// for both the device and the simulator, in this case the simulator is told by its (x86) architecture.
+func (slice Slice) Platform() Platform {
+	byteOrder := slice.File.ByteOrder
+	for _, load := range slice.File.Loads {
+		raw := load.Raw()
+		if len(raw) < 8 {
+			continue
+		}
+
+		switch byteOrder.Uint32(raw[0:4]) {
+		case loadCmdBuildVersion:
+			if len(raw) >= 12 {
+				return Platform(byteOrder.Uint32(raw[8:12]))
+			}
+		case loadCmdVersionMinIPhoneOS:
+			if slice.File.Cpu == macho.Cpu386 || slice.File.Cpu == macho.CpuAmd64 {
+				return PlatformIOSSimulator
+			}
+			return PlatformIOS
+		}
+	}
+	return PlatformUnknown
+}
+
+// IsSimulator reports whether the slice is built for the iOS Simulator.
+// If the platform can not be read, only the x86 architectures are considered as simulator slices.
+func (slice Slice) IsSimulator() bool {
+	if platform := slice.Platform(); platform != PlatformUnknown {
+		return platform == PlatformIOSSimulator
+	}
+	return slice.File.Cpu == macho.Cpu386 || slice.File.Cpu == macho.CpuAmd64
+}
+
+// HasSymbolContaining reports whether any slice's symbol table has a symbol containing one of the given substrings.
+func (file *File) HasSymbolContaining(substrs ...string) bool {
+	for _, slice := range file.Slices {
+		if slice.File.Symtab == nil {
+			continue
+		}
+
+		for _, symbol := range slice.File.Symtab.Syms {
+			for _, substr := range substrs {
+				if strings.Contains(symbol.Name, substr) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// UUID returns the LC_UUID of the slice, formatted the way dwarfdump prints it.
+func (slice Slice) UUID() (string, bool) {
+	for _, load := range slice.File.Loads {
+		raw := load.Raw()
+		if len(raw) < 24 || slice.File.ByteOrder.Uint32(raw[0:4]) != loadCmdUUID {
+			continue
+		}
+
+		uuid := raw[8:24]
+		return strings.ToUpper(fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])), true
+	}
+	return "", false
+}
+
+// UUIDs returns the LC_UUID of every slice, by architecture.
+func (file *File) UUIDs() map[string]string {
+	uuids := map[string]string{}
+	for _, slice := range file.Slices {
+		if uuid, ok := slice.UUID(); ok {
+			uuids[slice.Arch] = uuid
+		}
+	}
+	return uuids
+}
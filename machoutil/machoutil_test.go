@@ -0,0 +1,100 @@
+package machoutil
+
+import (
+	"debug/macho"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func openFixture(t *testing.T, name string) *File {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %s", err)
+	}
+
+	file, err := NewFileFromContent(content)
+	if err != nil {
+		t.Fatalf("failed to parse fixture: %s", err)
+	}
+	return file
+}
+
+func TestSlices(t *testing.T) {
+	tests := []struct {
+		fixture       string
+		wantArchs     []string
+		wantPlatforms []Platform
+		wantSimulator []bool
+	}{
+		{"device_arm64", []string{"arm64"}, []Platform{PlatformIOS}, []bool{false}},
+		{"simulator_arm64", []string{"arm64"}, []Platform{PlatformIOSSimulator}, []bool{true}},
+		{"simulator_x86_64_version_min", []string{"x86_64"}, []Platform{PlatformIOSSimulator}, []bool{true}},
+		{"no_platform_arm64", []string{"arm64"}, []Platform{PlatformUnknown}, []bool{false}},
+		{"universal_armv7_arm64", []string{"armv7", "arm64"}, []Platform{PlatformIOS, PlatformIOS}, []bool{false, false}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			file := openFixture(t, test.fixture)
+
+			if archs := file.Archs(); !reflect.DeepEqual(archs, test.wantArchs) {
+				t.Errorf("archs: got %v, want %v", archs, test.wantArchs)
+			}
+
+			platforms := []Platform{}
+			simulator := []bool{}
+			for _, slice := range file.Slices {
+				platforms = append(platforms, slice.Platform())
+				simulator = append(simulator, slice.IsSimulator())
+			}
+			if !reflect.DeepEqual(platforms, test.wantPlatforms) {
+				t.Errorf("platforms: got %v, want %v", platforms, test.wantPlatforms)
+			}
+			if !reflect.DeepEqual(simulator, test.wantSimulator) {
+				t.Errorf("simulator: got %v, want %v", simulator, test.wantSimulator)
+			}
+		})
+	}
+}
+
+func TestUUIDs(t *testing.T) {
+	file := openFixture(t, "universal_armv7_arm64")
+
+	want := map[string]string{
+		"armv7": "30313233-3435-3637-3839-3A3B3C3D3E3F",
+		"arm64": "10111213-1415-1617-1819-1A1B1C1D1E1F",
+	}
+	if uuids := file.UUIDs(); !reflect.DeepEqual(uuids, want) {
+		t.Errorf("got %v, want %v", uuids, want)
+	}
+}
+
+func TestArchName(t *testing.T) {
+	tests := []struct {
+		cpu    uint32
+		subCpu uint32
+		want   string
+	}{
+		{7, 3, "i386"},
+		{0x01000007, 3, "x86_64"},
+		{12, 9, "armv7"},
+		{12, 11, "armv7s"},
+		{12, 0, "arm"},
+		{0x0100000c, 0, "arm64"},
+		{0x0100000c, 0x80000002, "arm64e"},
+	}
+
+	for _, test := range tests {
+		if got := ArchName(macho.Cpu(test.cpu), test.subCpu); got != test.want {
+			t.Errorf("ArchName(%#x, %#x): got %q, want %q", test.cpu, test.subCpu, got, test.want)
+		}
+	}
+}
+
+func TestNewFileInvalid(t *testing.T) {
+	if _, err := NewFileFromContent([]byte("not a Mach-O binary")); err == nil {
+		t.Errorf("expected error")
+	}
+}
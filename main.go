@@ -5,18 +5,41 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/bitrise-io/go-utils/command"
 	"github.com/bitrise-io/go-utils/fileutil"
 	"github.com/bitrise-io/go-utils/log"
 	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/assembly"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/catalog"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/devices"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/dsym"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/estimate"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/failure"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/hints"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/ipa"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/nuget"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/pairing"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/profile"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/progress"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/rules"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/runoptions"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/series"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/symbolicate"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/testcloud"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/testfilter"
 	"github.com/bitrise-tools/go-steputils/input"
 	"github.com/bitrise-tools/go-steputils/tools"
+	"github.com/bitrise-tools/go-xamarin/analyzers/solution"
 	"github.com/bitrise-tools/go-xamarin/builder"
 	"github.com/bitrise-tools/go-xamarin/constants"
 	"github.com/bitrise-tools/go-xamarin/tools/buildtools"
-	"github.com/bitrise-tools/go-xamarin/tools/testcloud"
 	shellquote "github.com/kballard/go-shellquote"
 )
 
@@ -27,15 +50,35 @@ type ConfigsModel struct {
 	Devices string
 	Series  string
 
-	XamarinSolution      string
-	XamarinConfiguration string
-	XamarinPlatform      string
-
-	IsAsync         string
-	Parallelization string
-	CustomOptions   string
-	BuildTool       string
-	DeployDir       string
+	Categories  string
+	Fixtures    string
+	DeviceRules string
+	TestParams  string
+	Locales     string
+
+	DeviceCatalog             string
+	TestDurations             string
+	DeviceMinutesBudget       string
+	IgnoreDeviceMinutesBudget string
+
+	XamarinSolution       string
+	XamarinConfiguration  string
+	XamarinPlatform       string
+	TestProjectMapping    string
+	InferTestProjectPairs string
+
+	IsAsync               string
+	Parallelization       string
+	CustomOptions         string
+	BuildTool             string
+	PreflightCheck        string
+	DSYMMismatch          string
+	AppNameFromIPA        string
+	StageAssemblyDir      string
+	FailFast              string
+	ConcurrentSubmissions string
+	HeartbeatInterval     string
+	DeployDir             string
 }
 
 func createConfigsModelFromEnvs() ConfigsModel {
@@ -45,15 +88,35 @@ func createConfigsModelFromEnvs() ConfigsModel {
 		Devices: os.Getenv("test_cloud_devices"),
 		Series:  os.Getenv("test_cloud_series"),
 
-		XamarinSolution:      os.Getenv("xamarin_project"),
-		XamarinConfiguration: os.Getenv("xamarin_configuration"),
-		XamarinPlatform:      os.Getenv("xamarin_platform"),
-
-		IsAsync:         os.Getenv("test_cloud_is_async"),
-		Parallelization: os.Getenv("test_cloud_parallelization"),
-		CustomOptions:   os.Getenv("other_parameters"),
-		BuildTool:       os.Getenv("build_tool"),
-		DeployDir:       os.Getenv("BITRISE_DEPLOY_DIR"),
+		Categories:  os.Getenv("test_cloud_categories"),
+		Fixtures:    os.Getenv("test_cloud_fixtures"),
+		DeviceRules: os.Getenv("test_cloud_device_rules"),
+		TestParams:  os.Getenv("test_cloud_test_params"),
+		Locales:     os.Getenv("test_cloud_locales"),
+
+		DeviceCatalog:             os.Getenv("device_catalog"),
+		TestDurations:             os.Getenv("test_durations_result"),
+		DeviceMinutesBudget:       os.Getenv("device_minutes_budget"),
+		IgnoreDeviceMinutesBudget: os.Getenv("ignore_device_minutes_budget"),
+
+		XamarinSolution:       os.Getenv("xamarin_project"),
+		XamarinConfiguration:  os.Getenv("xamarin_configuration"),
+		XamarinPlatform:       os.Getenv("xamarin_platform"),
+		TestProjectMapping:    os.Getenv("test_project_mapping"),
+		InferTestProjectPairs: os.Getenv("infer_test_project_pairs"),
+
+		IsAsync:               os.Getenv("test_cloud_is_async"),
+		Parallelization:       os.Getenv("test_cloud_parallelization"),
+		CustomOptions:         os.Getenv("other_parameters"),
+		BuildTool:             os.Getenv("build_tool"),
+		PreflightCheck:        os.Getenv("ipa_preflight_check"),
+		DSYMMismatch:          os.Getenv("dsym_mismatch"),
+		AppNameFromIPA:        os.Getenv("app_name_from_ipa"),
+		StageAssemblyDir:      os.Getenv("stage_assembly_dir"),
+		FailFast:              os.Getenv("fail_fast"),
+		ConcurrentSubmissions: os.Getenv("concurrent_submissions"),
+		HeartbeatInterval:     os.Getenv("heartbeat_interval"),
+		DeployDir:             os.Getenv("BITRISE_DEPLOY_DIR"),
 	}
 }
 
@@ -64,19 +127,38 @@ func (configs ConfigsModel) print() {
 	log.Printf("- APIKey: %s", configs.APIKey)
 	log.Printf("- Devices: %s", configs.Devices)
 	log.Printf("- Series: %s", configs.Series)
+	log.Printf("- Categories: %s", configs.Categories)
+	log.Printf("- Fixtures: %s", configs.Fixtures)
+	log.Printf("- DeviceRules: %s", configs.DeviceRules)
+	log.Printf("- TestParams: %s", runoptions.RedactTestParamsInput(configs.TestParams))
+	log.Printf("- Locales: %s", configs.Locales)
+
+	log.Printf("- DeviceCatalog: %s", configs.DeviceCatalog)
+	log.Printf("- TestDurations: %s", configs.TestDurations)
+	log.Printf("- DeviceMinutesBudget: %s", configs.DeviceMinutesBudget)
+	log.Printf("- IgnoreDeviceMinutesBudget: %s", configs.IgnoreDeviceMinutesBudget)
 
 	log.Infof("Config:")
 
 	log.Printf("- XamarinSolution: %s", configs.XamarinSolution)
 	log.Printf("- XamarinConfiguration: %s", configs.XamarinConfiguration)
 	log.Printf("- XamarinPlatform: %s", configs.XamarinPlatform)
+	log.Printf("- TestProjectMapping: %s", configs.TestProjectMapping)
+	log.Printf("- InferTestProjectPairs: %s", configs.InferTestProjectPairs)
 
 	log.Infof("Debug:")
 
 	log.Printf("- IsAsync: %s", configs.IsAsync)
 	log.Printf("- Parallelization: %s", configs.Parallelization)
-	log.Printf("- CustomOptions: %s", configs.CustomOptions)
+	log.Printf("- CustomOptions: %s", runoptions.RedactCustomOptions(configs.CustomOptions))
 	log.Printf("- BuildTool: %s", configs.BuildTool)
+	log.Printf("- PreflightCheck: %s", configs.PreflightCheck)
+	log.Printf("- DSYMMismatch: %s", configs.DSYMMismatch)
+	log.Printf("- AppNameFromIPA: %s", configs.AppNameFromIPA)
+	log.Printf("- StageAssemblyDir: %s", configs.StageAssemblyDir)
+	log.Printf("- FailFast: %s", configs.FailFast)
+	log.Printf("- ConcurrentSubmissions: %s", configs.ConcurrentSubmissions)
+	log.Printf("- HeartbeatInterval: %s", configs.HeartbeatInterval)
 	log.Printf("- DeployDir: %s", configs.DeployDir)
 }
 
@@ -90,9 +172,44 @@ func (configs ConfigsModel) validate() error {
 	if err := input.ValidateIfNotEmpty(configs.Devices); err != nil {
 		return fmt.Errorf("Devices - %s", err)
 	}
+	if _, err := devices.ParseSelections(configs.Devices); err != nil {
+		return fmt.Errorf("Devices - %s", err)
+	}
 	if err := input.ValidateIfNotEmpty(configs.Series); err != nil {
 		return fmt.Errorf("Series - %s", err)
 	}
+	if err := series.Validate(configs.Series); err != nil {
+		return fmt.Errorf("Series - %s", err)
+	}
+
+	if _, err := runoptions.ParseTestParams(configs.TestParams); err != nil {
+		return fmt.Errorf("TestParams - %s", err)
+	}
+	if _, err := runoptions.ParseLocales(configs.Locales); err != nil {
+		return fmt.Errorf("Locales - %s", err)
+	}
+
+	if configs.DeviceCatalog != "" {
+		if err := input.ValidateIfPathExists(configs.DeviceCatalog); err != nil {
+			return fmt.Errorf("DeviceCatalog - %s", err)
+		}
+	}
+	if configs.TestDurations != "" {
+		if err := input.ValidateIfPathExists(configs.TestDurations); err != nil {
+			return fmt.Errorf("TestDurations - %s", err)
+		}
+	}
+	if configs.DeviceMinutesBudget != "" {
+		if budget, err := strconv.ParseFloat(configs.DeviceMinutesBudget, 64); err != nil || budget <= 0 {
+			return fmt.Errorf("DeviceMinutesBudget - should be a positive number, got: %s", configs.DeviceMinutesBudget)
+		}
+		if configs.DeviceCatalog == "" {
+			return fmt.Errorf("DeviceMinutesBudget - requires DeviceCatalog to count the devices")
+		}
+	}
+	if err := input.ValidateWithOptions(configs.IgnoreDeviceMinutesBudget, "yes", "no"); err != nil {
+		return fmt.Errorf("IgnoreDeviceMinutesBudget - %s", err)
+	}
 
 	if err := input.ValidateIfPathExists(configs.XamarinSolution); err != nil {
 		return fmt.Errorf("XamarinSolution - %s", err)
@@ -104,10 +221,36 @@ func (configs ConfigsModel) validate() error {
 		return fmt.Errorf("XamarinPlatform - %s", err)
 	}
 
+	if err := input.ValidateWithOptions(configs.InferTestProjectPairs, "yes", "no"); err != nil {
+		return fmt.Errorf("InferTestProjectPairs - %s", err)
+	}
+
 	if err := input.ValidateWithOptions(configs.BuildTool, "msbuild", "xbuild"); err != nil {
 		return fmt.Errorf("BuildTool - %s", err)
 	}
 
+	if err := input.ValidateWithOptions(configs.PreflightCheck, "yes", "no"); err != nil {
+		return fmt.Errorf("PreflightCheck - %s", err)
+	}
+	if err := input.ValidateWithOptions(configs.DSYMMismatch, "warn", "fail"); err != nil {
+		return fmt.Errorf("DSYMMismatch - %s", err)
+	}
+	if err := input.ValidateWithOptions(configs.AppNameFromIPA, "yes", "no"); err != nil {
+		return fmt.Errorf("AppNameFromIPA - %s", err)
+	}
+	if err := input.ValidateWithOptions(configs.StageAssemblyDir, "yes", "no"); err != nil {
+		return fmt.Errorf("StageAssemblyDir - %s", err)
+	}
+	if err := input.ValidateWithOptions(configs.FailFast, "yes", "no"); err != nil {
+		return fmt.Errorf("FailFast - %s", err)
+	}
+	if concurrentSubmissions, err := strconv.Atoi(configs.ConcurrentSubmissions); err != nil || concurrentSubmissions < 1 {
+		return fmt.Errorf("ConcurrentSubmissions - should be a positive number, got: %s", configs.ConcurrentSubmissions)
+	}
+	if heartbeatInterval, err := strconv.Atoi(configs.HeartbeatInterval); err != nil || heartbeatInterval < 0 {
+		return fmt.Errorf("HeartbeatInterval - should be a non-negative number of seconds, got: %s", configs.HeartbeatInterval)
+	}
+
 	return nil
 }
 
@@ -134,272 +277,468 @@ func testResultLogContent(pth string) (string, error) {
 	return content, nil
 }
 
-func failf(format string, v ...interface{}) {
-	log.Errorf(format, v...)
-	if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_RESULT", "failed"); err != nil {
-		log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_RESULT", err)
+func symbolicateTestResult(dsymPth, resultLogPth, resultLog string) string {
+	symbolicator, err := symbolicate.New(dsymPth)
+	if err != nil {
+		log.Warnf("Failed to load dSYM for symbolication, error: %s", err)
+		return resultLog
 	}
-	os.Exit(1)
-}
-
-func main() {
-	configs := createConfigsModelFromEnvs()
 
-	fmt.Println()
-	configs.print()
+	symbolicateCrashLogs(symbolicator, resultLogPth, resultLog)
 
-	if err := configs.validate(); err != nil {
-		failf("Issue with input: %s", err)
+	symbolicated := symbolicator.SymbolicateXML(resultLog)
+	if symbolicated == resultLog {
+		return resultLog
 	}
 
-	//
-	// build
-	fmt.Println()
-	log.Infof("Building all iOS Xamarin UITest and Referred Projects in solution: %s", configs.XamarinSolution)
-
-	buildTool := buildtools.Msbuild
-	if configs.BuildTool == "xbuild" {
-		buildTool = buildtools.Xbuild
+	if err := fileutil.WriteStringToFile(resultLogPth, symbolicated); err != nil {
+		log.Warnf("Failed to write symbolicated test result, error: %s", err)
 	}
+	log.Donef("Crash stack traces in test result symbolicated")
 
-	builder, err := builder.New(configs.XamarinSolution, []constants.SDK{constants.SDKIOS}, buildTool)
+	return symbolicated
+}
+
+// symbolicateCrashLogs rewrites the crash logs attached to the test cases of the test result.
+func symbolicateCrashLogs(symbolicator *symbolicate.Symbolicator, resultLogPth, resultLog string) {
+	pths, err := symbolicate.CrashLogAttachments(resultLog, filepath.Dir(resultLogPth))
 	if err != nil {
-		failf("Failed to create xamarin builder, error: %s", err)
+		log.Warnf("Failed to read test result attachments, error: %s", err)
+		return
 	}
 
-	callback := func(solutionName string, projectName string, sdk constants.SDK, testFramework constants.TestFramework, commandStr string, alreadyPerformed bool) {
-		fmt.Println()
-		if projectName == "" {
-			log.Infof("Building solution: %s", solutionName)
-		} else {
-			if testFramework == constants.TestFrameworkXamarinUITest {
-				log.Infof("Building test project: %s", projectName)
-			} else {
-				log.Infof("Building project: %s", projectName)
-			}
+	for _, pth := range pths {
+		if exist, err := pathutil.IsPathExists(pth); err != nil || !exist {
+			log.Printf("Attached crash log not found: %s", pth)
+			continue
 		}
 
-		log.Donef("$ %s", commandStr)
+		content, err := fileutil.ReadStringFromFile(pth)
+		if err != nil {
+			log.Warnf("Failed to read attached crash log (%s), error: %s", pth, err)
+			continue
+		}
 
-		if alreadyPerformed {
-			log.Warnf("build command already performed, skipping...")
+		symbolicated := symbolicator.Symbolicate(content)
+		if symbolicated == content {
+			continue
 		}
 
-		fmt.Println()
+		if err := fileutil.WriteStringToFile(pth, symbolicated); err != nil {
+			log.Warnf("Failed to write symbolicated crash log (%s), error: %s", pth, err)
+			continue
+		}
+		log.Donef("Attached crash log symbolicated: %s", pth)
 	}
+}
 
-	startTime := time.Now()
-	warnings, err := builder.BuildAllUITestableXamarinProjects(configs.XamarinConfiguration, configs.XamarinPlatform, nil, callback)
-	endTime := time.Now()
+func symbolicateMessages(dsymPth string, messages []string) []string {
+	symbolicator, err := symbolicate.New(dsymPth)
+	if err != nil {
+		log.Warnf("Failed to load dSYM for symbolication, error: %s", err)
+		return messages
+	}
 
-	for _, warning := range warnings {
-		log.Warnf(warning)
+	symbolicated := []string{}
+	for _, message := range messages {
+		symbolicated = append(symbolicated, symbolicator.Symbolicate(message))
 	}
-	if err != nil {
-		failf("Build failed, error: %s", err)
+	return symbolicated
+}
+
+func readExecutableAndDSYMUUIDs(app *ipa.App, appErr error, dsymPth string) (map[string]string, map[string]string, error) {
+	if appErr != nil {
+		return nil, nil, fmt.Errorf("failed to read app executable UUIDs, error: %s", appErr)
 	}
 
-	projectOutputMap, err := builder.CollectProjectOutputs(configs.XamarinConfiguration, configs.XamarinPlatform, startTime, endTime)
+	dsymUUIDs, err := dsym.UUIDs(dsymPth)
 	if err != nil {
-		failf("Failed to collect project outputs, error: %s", err)
+		return nil, nil, fmt.Errorf("failed to read dSYM UUIDs, error: %s", err)
 	}
 
-	testProjectOutputMap, warnings, err := builder.CollectXamarinUITestProjectOutputs(configs.XamarinConfiguration, configs.XamarinPlatform, startTime, endTime)
-	for _, warning := range warnings {
-		log.Warnf(warning)
-	}
+	return app.Binary.UUIDs(), dsymUUIDs, nil
+}
+
+func exportTestInventory(pth string, inventories []assembly.InventoryModel) error {
+	content, err := json.MarshalIndent(inventories, "", "  ")
 	if err != nil {
-		failf("Failed to collect test project output, error: %s", err)
+		return err
 	}
-	if len(testProjectOutputMap) == 0 {
-		failf("No testable output generated")
+
+	if err := fileutil.WriteBytesToFile(pth, content); err != nil {
+		return err
 	}
-	// ---
 
-	//
-	// Test Cloud submit
-	solutionDir := filepath.Dir(configs.XamarinSolution)
-	pattern := filepath.Join(solutionDir, "packages/Xamarin.UITest.*/tools/test-cloud.exe")
-	testClouds, err := filepath.Glob(pattern)
-	if err != nil {
-		failf("Failed to find test-cloud.exe path with pattern (%s), error: %s", pattern, err)
+	return tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_INVENTORY_PATH", pth)
+}
+
+func exportAppMetadata(metadata ipa.MetadataModel) {
+	envs := []struct {
+		key   string
+		value string
+	}{
+		{"BITRISE_XAMARIN_TEST_APP_BUNDLE_ID", metadata.BundleID},
+		{"BITRISE_XAMARIN_TEST_APP_DISPLAY_NAME", metadata.DisplayName},
+		{"BITRISE_XAMARIN_TEST_APP_VERSION", metadata.ShortVersion},
+		{"BITRISE_XAMARIN_TEST_APP_BUILD_NUMBER", metadata.BuildNumber},
+		{"BITRISE_XAMARIN_TEST_APP_MINIMUM_OS_VERSION", metadata.MinimumOSVersion},
 	}
-	if len(testClouds) == 0 {
-		if err != nil {
-			failf("No test-cloud.exe found path with pattern (%s)", pattern)
+
+	for _, env := range envs {
+		if err := tools.ExportEnvironmentWithEnvman(env.key, env.value); err != nil {
+			log.Warnf("Failed to export environment: %s, error: %s", env.key, err)
 		}
 	}
+}
 
-	testCloud, err := testcloud.NewModel(testClouds[0])
+// stageAssemblyDir copies the files into a temporary directory, keeping their path relative to the assembly dir.
+func stageAssemblyDir(assemblyDir string, files []string) (string, error) {
+	stagingDir, err := pathutil.NormalizedOSTempDirPath("assembly_dir")
 	if err != nil {
-		failf("Failed to create test cloud model, error: %s", err)
+		return "", err
 	}
 
-	testCloud.SetAPIKey(configs.APIKey)
-	testCloud.SetUser(configs.User)
-	testCloud.SetDevices(configs.Devices)
-	testCloud.SetIsAsyncJSON(configs.IsAsync == "yes")
-	testCloud.SetSeries(configs.Series)
+	for _, file := range files {
+		relPth, err := filepath.Rel(assemblyDir, file)
+		if err != nil {
+			return "", err
+		}
 
-	// If test cloud runs in asnyc mode test result will not be saved into file
-	resultLogPth := filepath.Join(configs.DeployDir, "TestResult.xml")
-	if configs.IsAsync != "yes" {
-		testCloud.SetNunitXMLPth(resultLogPth)
+		dst := filepath.Join(stagingDir, relPth)
+		if err := pathutil.EnsureDirExist(filepath.Dir(dst)); err != nil {
+			return "", err
+		}
+		if err := command.CopyFile(file, dst); err != nil {
+			return "", err
+		}
 	}
 
-	// Parallelization
-	if configs.Parallelization != "none" {
-		parallelization, err := testcloud.ParseParallelization(configs.Parallelization)
+	return stagingDir, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	size := int64(0)
+	err := filepath.Walk(dir, func(pth string, info os.FileInfo, err error) error {
 		if err != nil {
-			failf("Failed to parse parallelization, error: %s", err)
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
 		}
+		return nil
+	})
+	return size, err
+}
 
-		testCloud.SetParallelization(parallelization)
+func formatSize(size int64) string {
+	return fmt.Sprintf("%.2f MB", float64(size)/1024/1024)
+}
+
+type xamarinUITestVersionModel struct {
+	projectName string
+	// referenced is the version as it is referenced by the project
+	referenced string
+	// version is the resolved version, empty if the referenced version can not be resolved
+	version string
+}
+
+// xamarinUITestVersions returns the Xamarin.UITest package version of the given UITest projects, in solution file order.
+func xamarinUITestVersions(solutionPth string, solutionOrder map[string]int, testProjectNames map[string]bool) ([]xamarinUITestVersionModel, error) {
+	sln, err := solution.New(solutionPth, true)
+	if err != nil {
+		return nil, err
 	}
-	// ---
 
-	// Custom Options
-	if configs.CustomOptions != "" {
-		options, err := shellquote.Split(configs.CustomOptions)
+	versions := []xamarinUITestVersionModel{}
+	for _, proj := range pairing.Projects(sln, solutionOrder) {
+		if proj.TestFramework != constants.TestFrameworkXamarinUITest || !testProjectNames[proj.Name] {
+			continue
+		}
+
+		referenced, err := nuget.PackageVersion(proj.Pth, "Xamarin.UITest")
 		if err != nil {
-			failf("Failed to split params (%s), error: %s", configs.CustomOptions, err)
+			return nil, fmt.Errorf("failed to read Xamarin.UITest version of project (%s), error: %s", proj.Name, err)
 		}
 
-		testCloud.SetCustomOptions(options...)
+		version, _ := nuget.ResolveVersion(referenced)
+		versions = append(versions, xamarinUITestVersionModel{projectName: proj.Name, referenced: referenced, version: version})
 	}
-	// ---
 
-	// Artifacts
-	resultLog := ""
+	return versions, nil
+}
+
+// selectTestCloud returns the test-cloud.exe and its Xamarin.UITest version, which matches the version
+// of every test project, preferring the highest version.
+// If none of them matches, the highest version is returned.
+func selectTestCloud(testCloudPths []string, uiTestVersions []xamarinUITestVersionModel) (string, string) {
+	type testCloudModel struct {
+		pth     string
+		version string
+	}
+
+	testClouds := []testCloudModel{}
+	for _, pth := range testCloudPths {
+		testClouds = append(testClouds, testCloudModel{pth: pth, version: nuget.PackageVersionFromPath(pth, "Xamarin.UITest")})
+	}
+	sort.SliceStable(testClouds, func(i, j int) bool {
+		if testClouds[i].version == "" || testClouds[j].version == "" {
+			return testClouds[j].version == "" && testClouds[i].version != ""
+		}
+		return nuget.CompareVersions(testClouds[i].version, testClouds[j].version) > 0
+	})
 
-	for testProjectName, testProjectOutput := range testProjectOutputMap {
-		if len(testProjectOutput.ReferredProjectNames) == 0 {
-			log.Warnf("Test project (%s) does not refers to any project, skipping...", testProjectName)
+	for _, testCloud := range testClouds {
+		if testCloud.version == "" {
 			continue
 		}
 
-		for _, projectName := range testProjectOutput.ReferredProjectNames {
-			projectOutput, ok := projectOutputMap[projectName]
-			if !ok {
-				continue
+		matches := true
+		for _, uiTestVersion := range uiTestVersions {
+			if uiTestVersion.version != "" && !nuget.MatchVersion(uiTestVersion.version, testCloud.version) {
+				matches = false
+				break
 			}
+		}
+		if matches {
+			return testCloud.pth, testCloud.version
+		}
+	}
 
-			ipaPth := ""
-			dsymPth := ""
-			for _, output := range projectOutput.Outputs {
-				if output.OutputType == constants.OutputTypeIPA {
-					ipaPth = output.Pth
-				}
-
-				if output.OutputType == constants.OutputTypeDSYM {
-					dsymPth = output.Pth
-				}
-			}
+	return testClouds[0].pth, testClouds[0].version
+}
 
-			if ipaPth == "" {
-				log.Warnf("No ipa generated for project: %s", projectName)
+// formatTable aligns the columns of the rows.
+func formatTable(rows [][]string) []string {
+	widths := []int{}
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
 			}
-			if dsymPth == "" {
-				log.Warnf("No dsym generated for project: %s", projectName)
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
 			}
+		}
+	}
 
-			// Submit
-			fmt.Println()
-			log.Infof("Testing (%s) against (%s)", testProjectName, projectName)
-			log.Printf("test dll: %s", testProjectOutput.Output.Pth)
-			log.Printf("ipa: %s", ipaPth)
-			log.Printf("dsym: %s", dsymPth)
+	lines := []string{}
+	for _, row := range rows {
+		cells := []string{}
+		for i, cell := range row {
+			cells = append(cells, fmt.Sprintf("%-*s", widths[i], cell))
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, " | "), " "))
+	}
+	return lines
+}
 
-			testCloud.SetAssemblyDir(filepath.Dir(testProjectOutput.Output.Pth))
-			testCloud.SetIPAPth(ipaPth)
-			testCloud.SetDSYMPth(dsymPth)
+// stepFlags returns the test-cloud.exe options emitted by the step, which can not be given in other_parameters.
+// --dsym is not listed, the step does not pass the generated dSYM if other_parameters gives one.
+// --test-params is always listed, test parameters are given in test_cloud_test_params, which is redacted in the logs.
+func stepFlags(configs ConfigsModel) []string {
+	flags := []string{"--user", "--assembly-dir", "--devices", "--series", "--test-params"}
+	if configs.IsAsync == "yes" {
+		flags = append(flags, "--async-json")
+	} else {
+		flags = append(flags, "--nunit-xml")
+	}
+	switch configs.Parallelization {
+	case string(testcloud.ParallelizationByTestChunk):
+		flags = append(flags, "--test-chunk")
+	case string(testcloud.ParallelizationByTestFixture):
+		flags = append(flags, "--fixture-chunk")
+	}
+	if configs.Locales != "" {
+		flags = append(flags, "--locale")
+	}
+	return flags
+}
 
-			fmt.Println()
-			log.Infof("Submitting:")
-			log.Donef("$ %s", testCloud.PrintableCommand())
+func containsOption(options []string, option string) bool {
+	for _, opt := range options {
+		if opt == option || strings.HasPrefix(opt, option+"=") || strings.HasPrefix(opt, option+":") {
+			return true
+		}
+	}
+	return false
+}
 
-			lines := []string{}
-			callback := func(line string) {
-				log.Printf(line)
+type pairResultModel struct {
+	pair        pairing.Model
+	deviceLabel string
+	locale      string
+	failed      bool
+	reason      failure.Reason
+	skipped     bool
+	message     string
+	testRunID   string
+	// resultLogPth is the NUnit result of the test run, if test-cloud.exe wrote it
+	resultLogPth string
+}
 
-				lines = append(lines, line)
-			}
+func (pairResult pairResultModel) name() string {
+	return runName(pairResult.pair, pairResult.deviceLabel, pairResult.locale)
+}
 
-			err := testCloud.Submit(callback)
+type submissionModel struct {
+	pair              pairing.Model
+	deviceLabel       string
+	deviceSelectionID string
+	locale            string
+	filters           []testfilter.Model
+	testCloud         testcloud.Model
+	dsymPth           string
+	resultLogPth      string
+
+	// planErr is set if the pair can not be submitted, it is reported in order with the submitted test runs
+	planErr    error
+	planReason failure.Reason
+}
 
-			// If test cloud runs in asnyc mode test result will not be saved into file
-			if configs.IsAsync != "yes" {
-				testLog, logErr := testResultLogContent(resultLogPth)
-				if logErr != nil {
-					log.Warnf("Failed to read test result, error: %s", logErr)
-				}
-				resultLog = testLog
-			}
+func (submission submissionModel) name() string {
+	return runName(submission.pair, submission.deviceLabel, submission.locale)
+}
 
-			if err != nil {
-				log.Errorf("Submit failed, error: %s", err)
+// planFailure fails the step if failFast is set,
+// otherwise it logs the error and returns the failed submission of the pair.
+func planFailure(failFast bool, pair pairing.Model, reason failure.Reason, format string, v ...interface{}) submissionModel {
+	if failFast {
+		failf(reason, format, v...)
+	}
 
-				if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_RESULT", "failed"); err != nil {
-					log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_RESULT", err)
-				}
+	err := fmt.Errorf(format, v...)
+	log.Errorf("%s", err)
+	log.Warnf("Skipping (%s)", runName(pair, "", ""))
+	return submissionModel{pair: pair, planErr: err, planReason: reason}
+}
 
-				if resultLog != "" {
-					if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_FULL_RESULTS_TEXT", resultLog); err != nil {
-						log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_FULL_RESULTS_TEXT", err)
-					}
-				}
+// runName identifies a test run in the logs and outputs.
+func runName(pair pairing.Model, deviceLabel, locale string) string {
+	name := fmt.Sprintf("%s -> %s", pair.TestProject, pair.AppProject)
+
+	labels := []string{}
+	if deviceLabel != "" {
+		labels = append(labels, deviceLabel)
+	}
+	if locale != "" {
+		labels = append(labels, locale)
+	}
+	if len(labels) > 0 {
+		name += fmt.Sprintf(" (%s)", strings.Join(labels, ", "))
+	}
+	return name
+}
+
+type submissionResultModel struct {
+	skipped    bool
+	err        error
+	failures   []string
+	output     []string
+	timings    []progress.Timing
+	jsonResult *JSONResultModel
+	jsonErr    error
+}
+
+func (result submissionResultModel) failed() bool {
+	return result.err != nil || (result.jsonResult != nil && len(result.jsonResult.ErrorMessages) > 0)
+}
+
+// heartbeat prints the elapsed time and the last known phase at every interval, until done is closed.
+func heartbeat(interval time.Duration, progressParser *progress.Parser, prefix string, done <-chan struct{}) {
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			log.Printf("%sStill submitting: %s elapsed, phase: %s", prefix, now.Sub(start)/time.Second*time.Second, progressParser.Phase())
+		}
+	}
+}
 
-				os.Exit(1)
+// submitTestRun submits the test run and prefixes its logs with the given prefix.
+func submitTestRun(submission submissionModel, isAsync bool, heartbeatInterval time.Duration, prefix string) submissionResultModel {
+	fmt.Println()
+	log.Infof("%sSubmitting:", prefix)
+	log.Donef("%s$ %s", prefix, submission.testCloud.PrintableCommand())
+
+	lines := []string{}
+	progressParser := progress.NewParser(func(event progress.Event) {
+		switch event.Type {
+		case progress.EventPhaseStarted:
+			if event.DeviceCount > 0 {
+				log.Infof("%sTest Cloud: %s on %d devices", prefix, event.Phase, event.DeviceCount)
+			} else {
+				log.Infof("%sTest Cloud: %s", prefix, event.Phase)
 			}
-			// ---
+		case progress.EventUploadProgress:
+			if event.Percent%25 == 0 {
+				log.Printf("%sUploading: %d%%", prefix, event.Percent)
+			}
+		case progress.EventFailure:
+			log.Errorf("%sTest Cloud: %s", prefix, event.Failure)
+		}
+	})
+	callback := func(line string) {
+		if !progressParser.ParseLine(line) {
+			log.Printf("%s%s", prefix, line)
+		}
+		lines = append(lines, line)
+	}
 
-			if configs.IsAsync == "yes" {
-				fmt.Println()
-				log.Infof("Preocessing json result:")
+	// test-cloud.exe may print nothing while the devices run, the heartbeat keeps the log alive
+	heartbeatDone := make(chan struct{})
+	heartbeatStopped := make(chan struct{})
+	go func() {
+		defer close(heartbeatStopped)
+		if heartbeatInterval > 0 {
+			heartbeat(heartbeatInterval, progressParser, prefix, heartbeatDone)
+		}
+	}()
 
-				jsonLine := ""
-				for _, line := range lines {
-					if strings.HasPrefix(line, "{") && strings.HasSuffix(line, "}") {
-						jsonLine = line
-					}
-				}
+	result := submissionResultModel{}
+	result.err = submission.testCloud.Submit(callback)
 
-				if jsonLine != "" {
-					var result JSONResultModel
-					if err := json.Unmarshal([]byte(jsonLine), &result); err != nil {
-						log.Errorf("Failed to unmarshal result, error: %s", err)
-					} else {
-						for _, errorMsg := range result.ErrorMessages {
-							log.Errorf(errorMsg)
-						}
-
-						if len(result.ErrorMessages) > 0 {
-							if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_RESULT", "failed"); err != nil {
-								log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_RESULT", err)
-							}
-
-							if resultLog != "" {
-								if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_FULL_RESULTS_TEXT", resultLog); err != nil {
-									log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_FULL_RESULTS_TEXT", err)
-								}
-							}
-
-							os.Exit(1)
-						}
-
-						if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_TO_RUN_ID", result.TestRunID); err != nil {
-							log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_TO_RUN_ID", err)
-						}
-
-						log.Donef("TestRunId (%s) is available in (%s) environment variable", result.TestRunID, "BITRISE_XAMARIN_TEST_TO_RUN_ID")
-					}
-				}
+	close(heartbeatDone)
+	<-heartbeatStopped
+
+	result.timings = progressParser.Finish()
+	result.failures = progressParser.Failures()
+	result.output = lines
+
+	if result.err == nil && isAsync {
+		jsonLine := ""
+		for _, line := range lines {
+			if strings.HasPrefix(line, "{") && strings.HasSuffix(line, "}") {
+				jsonLine = line
+			}
+		}
+
+		if jsonLine != "" {
+			var jsonResult JSONResultModel
+			if err := json.Unmarshal([]byte(jsonLine), &jsonResult); err != nil {
+				result.jsonErr = err
+			} else {
+				result.jsonResult = &jsonResult
 			}
 		}
 	}
-	// ---
 
-	if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_RESULT", "succeeded"); err != nil {
+	return result
+}
+
+var unsafeFileNameCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// fileNameComponent replaces the characters of the name which are not safe in file names.
+func fileNameComponent(name string) string {
+	return unsafeFileNameCharacters.ReplaceAllString(name, "_")
+}
+
+func exportTestResult(result, resultLog string) {
+	if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_RESULT", result); err != nil {
 		log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_RESULT", err)
 	}
 
@@ -409,3 +748,1177 @@ func main() {
 		}
 	}
 }
+
+// exportDeviceValues exports the values of the device selection labels, one `<label>: <values>` per line.
+func exportDeviceValues(key string, deviceLabels []string, values map[string][]string) {
+	lines := []string{}
+	for _, label := range deviceLabels {
+		if len(values[label]) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s", label, strings.Join(values[label], ", ")))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	if err := tools.ExportEnvironmentWithEnvman(key, strings.Join(lines, "\n")); err != nil {
+		log.Warnf("Failed to export environment: %s, error: %s", key, err)
+	}
+}
+
+// printHints prints the remediation hints of the known Test Cloud errors found in the messages.
+func printHints(messages []string) {
+	for _, hint := range hints.Collect(messages) {
+		fmt.Println()
+		log.Warnf("Hint: %s", hint.Title)
+		log.Printf(hint.Explanation)
+		for _, step := range hint.Steps {
+			log.Printf("- %s", step)
+		}
+	}
+}
+
+func exportFailure(reason failure.Reason, details string) {
+	if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_FAILURE_REASON", string(reason)); err != nil {
+		log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_FAILURE_REASON", err)
+	}
+	if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_FAILURE_DETAILS", details); err != nil {
+		log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_FAILURE_DETAILS", err)
+	}
+}
+
+func failf(reason failure.Reason, format string, v ...interface{}) {
+	log.Errorf(format, v...)
+	if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_RESULT", "failed"); err != nil {
+		log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_RESULT", err)
+	}
+	exportFailure(reason, fmt.Sprintf(format, v...))
+	os.Exit(1)
+}
+
+func main() {
+	configs := createConfigsModelFromEnvs()
+
+	fmt.Println()
+	configs.print()
+
+	if err := configs.validate(); err != nil {
+		failf(failure.ReasonInputError, "Issue with input: %s", err)
+	}
+
+	testProjectMapping := []pairing.Model{}
+	if configs.TestProjectMapping != "" {
+		mapping, err := pairing.ParseMapping(configs.TestProjectMapping)
+		if err != nil {
+			failf(failure.ReasonInputError, "Issue with input: TestProjectMapping - %s", err)
+		}
+		testProjectMapping = mapping
+	}
+
+	deviceCatalog := catalog.Model{}
+	if configs.DeviceCatalog != "" {
+		deviceSets, err := catalog.Open(configs.DeviceCatalog)
+		if err != nil {
+			failf(failure.ReasonInputError, "Issue with input: DeviceCatalog - %s", err)
+		}
+		deviceCatalog = deviceSets
+	}
+
+	if configs.DeviceRules != "" {
+		deviceRules, err := rules.Parse(configs.DeviceRules)
+		if err != nil {
+			failf(failure.ReasonInputError, "Issue with input: DeviceRules - %s", err)
+		}
+
+		env := rules.EnvironmentModel{
+			Branch:   os.Getenv("BITRISE_GIT_BRANCH"),
+			Workflow: os.Getenv("BITRISE_TRIGGERED_WORKFLOW_ID"),
+			Trigger:  rules.TriggerPush,
+		}
+		if os.Getenv("BITRISE_PULL_REQUEST") != "" {
+			env.Trigger = rules.TriggerPR
+		}
+
+		fmt.Println()
+		log.Infof("Selecting devices:")
+		log.Printf("branch: %s", env.Branch)
+		log.Printf("workflow: %s", env.Workflow)
+		log.Printf("trigger: %s", env.Trigger)
+
+		selectedDevices, i := rules.SelectDevices(deviceRules, env, configs.Devices)
+		if i == -1 {
+			log.Warnf("No rule matches the build, using the devices input: %s", selectedDevices)
+		} else {
+			rule := deviceRules[i]
+			log.Donef("Rule #%d (%s) matches, devices: %s", i+1, rule, selectedDevices)
+
+			configs.Devices = selectedDevices
+			if len(rule.Categories) > 0 {
+				log.Printf("categories: %s", strings.Join(rule.Categories, ", "))
+
+				categories := rule.Categories
+				if configs.Categories != "" {
+					categories = append([]string{configs.Categories}, categories...)
+				}
+				configs.Categories = strings.Join(categories, ",")
+			}
+
+			if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_DEVICE_RULE", rule.String()); err != nil {
+				log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_DEVICE_RULE", err)
+			}
+		}
+	}
+
+	//
+	// build
+	fmt.Println()
+	log.Infof("Building all iOS Xamarin UITest and Referred Projects in solution: %s", configs.XamarinSolution)
+
+	buildTool := buildtools.Msbuild
+	if configs.BuildTool == "xbuild" {
+		buildTool = buildtools.Xbuild
+	}
+
+	builder, err := builder.New(configs.XamarinSolution, []constants.SDK{constants.SDKIOS}, buildTool)
+	if err != nil {
+		failf(failure.ReasonBuildFailure, "Failed to create xamarin builder, error: %s", err)
+	}
+
+	callback := func(solutionName string, projectName string, sdk constants.SDK, testFramework constants.TestFramework, commandStr string, alreadyPerformed bool) {
+		fmt.Println()
+		if projectName == "" {
+			log.Infof("Building solution: %s", solutionName)
+		} else {
+			if testFramework == constants.TestFrameworkXamarinUITest {
+				log.Infof("Building test project: %s", projectName)
+			} else {
+				log.Infof("Building project: %s", projectName)
+			}
+		}
+
+		log.Donef("$ %s", commandStr)
+
+		if alreadyPerformed {
+			log.Warnf("build command already performed, skipping...")
+		}
+
+		fmt.Println()
+	}
+
+	startTime := time.Now()
+	var warnings []string
+	if len(testProjectMapping) > 0 || configs.InferTestProjectPairs == "yes" {
+		// mapped and inferred test projects do not refer to the app projects,
+		// the solution build builds the test projects and every app project is built for testing
+		if err := builder.BuildSolution(configs.XamarinConfiguration, configs.XamarinPlatform, callback); err != nil {
+			failf(failure.ReasonBuildFailure, "Build failed, error: %s", err)
+		}
+		warnings, err = builder.BuildAllProjects(configs.XamarinConfiguration, configs.XamarinPlatform, nil, callback)
+	} else {
+		warnings, err = builder.BuildAllUITestableXamarinProjects(configs.XamarinConfiguration, configs.XamarinPlatform, nil, callback)
+	}
+	endTime := time.Now()
+
+	for _, warning := range warnings {
+		log.Warnf(warning)
+	}
+	if err != nil {
+		failf(failure.ReasonBuildFailure, "Build failed, error: %s", err)
+	}
+
+	projectOutputMap, err := builder.CollectProjectOutputs(configs.XamarinConfiguration, configs.XamarinPlatform, startTime, endTime)
+	if err != nil {
+		failf(failure.ReasonArtifactNotFound, "Failed to collect project outputs, error: %s", err)
+	}
+
+	testProjectOutputMap, warnings, err := builder.CollectXamarinUITestProjectOutputs(configs.XamarinConfiguration, configs.XamarinPlatform, startTime, endTime)
+	for _, warning := range warnings {
+		log.Warnf(warning)
+	}
+	if err != nil {
+		failf(failure.ReasonArtifactNotFound, "Failed to collect test project output, error: %s", err)
+	}
+
+	// projects are processed in solution file order, then by name
+	solutionOrder, err := pairing.SolutionOrder(configs.XamarinSolution)
+	if err != nil {
+		log.Warnf("Failed to read project order of the solution, error: %s", err)
+	}
+
+	var sln solution.Model
+	if len(testProjectMapping) > 0 || configs.InferTestProjectPairs == "yes" {
+		sln, err = solution.New(configs.XamarinSolution, true)
+		if err != nil {
+			failf(failure.ReasonBuildFailure, "Failed to analyze solution, error: %s", err)
+		}
+	}
+
+	pairs := testProjectMapping
+	if len(pairs) > 0 {
+		mappedTestProjects := map[string]bool{}
+		for _, pair := range pairs {
+			if _, ok := projectOutputMap[pair.AppProject]; !ok {
+				failf(failure.ReasonArtifactNotFound, "No output generated for mapped app project: %s", pair.AppProject)
+			}
+
+			testProjectOutput, ok := testProjectOutputMap[pair.TestProject]
+			if !ok {
+				testProjectOutput, err = pairing.TestProjectOutput(sln, pair.TestProject, configs.XamarinConfiguration, configs.XamarinPlatform)
+				if err != nil {
+					failf(failure.ReasonArtifactNotFound, "Failed to collect mapped test project output, error: %s", err)
+				}
+			}
+			testProjectOutputMap[pair.TestProject] = testProjectOutput
+			mappedTestProjects[pair.TestProject] = true
+		}
+
+		// the mapping overrides the reference based pairing
+		for testProjectName := range testProjectOutputMap {
+			if !mappedTestProjects[testProjectName] {
+				delete(testProjectOutputMap, testProjectName)
+			}
+		}
+	} else if configs.InferTestProjectPairs == "yes" {
+		pairs = pairing.FromReferences(testProjectOutputMap, solutionOrder)
+
+		unpairedTestProjectNames := []string{}
+		for _, proj := range pairing.Projects(sln, solutionOrder) {
+			if proj.TestFramework != constants.TestFrameworkXamarinUITest {
+				continue
+			}
+			if testProjectOutput, ok := testProjectOutputMap[proj.Name]; !ok || len(testProjectOutput.ReferredProjectNames) == 0 {
+				unpairedTestProjectNames = append(unpairedTestProjectNames, proj.Name)
+			}
+		}
+
+		if len(unpairedTestProjectNames) > 0 {
+			fmt.Println()
+			log.Infof("Inferring app projects of test projects without project reference:")
+
+			inferredPairs, skipped := pairing.Infer(sln, unpairedTestProjectNames)
+			for _, inferred := range inferredPairs {
+				if _, ok := projectOutputMap[inferred.AppProject]; !ok {
+					log.Warnf("- %s: no output generated for inferred app project (%s)", inferred.TestProject, inferred.AppProject)
+					continue
+				}
+
+				testProjectOutput, ok := testProjectOutputMap[inferred.TestProject]
+				if !ok {
+					testProjectOutput, err = pairing.TestProjectOutput(sln, inferred.TestProject, configs.XamarinConfiguration, configs.XamarinPlatform)
+					if err != nil {
+						log.Warnf("- %s: %s", inferred.TestProject, err)
+						continue
+					}
+					testProjectOutputMap[inferred.TestProject] = testProjectOutput
+				}
+
+				log.Printf("- %s -> %s (confidence: %s, score: %d)", inferred.TestProject, inferred.AppProject, inferred.Confidence, inferred.Score)
+				for _, reason := range inferred.Reasons {
+					log.Printf("  %s", reason)
+				}
+
+				pairs = append(pairs, inferred.Model)
+			}
+
+			for _, reason := range skipped {
+				log.Warnf("- %s, skipping...", reason)
+			}
+		}
+	} else {
+		for _, testProjectName := range pairing.TestProjectNames(testProjectOutputMap, solutionOrder) {
+			if len(testProjectOutputMap[testProjectName].ReferredProjectNames) == 0 {
+				log.Warnf("Test project (%s) does not refers to any project, skipping...", testProjectName)
+			}
+		}
+		pairs = pairing.FromReferences(testProjectOutputMap, solutionOrder)
+	}
+
+	// the mapping defines the order of the mapped pairs,
+	// the discovered pairs are ordered by the position of the projects in the solution file
+	if len(testProjectMapping) == 0 {
+		pairing.Sort(pairs, solutionOrder)
+	}
+
+	if len(testProjectOutputMap) == 0 {
+		failf(failure.ReasonArtifactNotFound, "No testable output generated")
+	}
+	// ---
+
+	//
+	// Test inventory
+	fmt.Println()
+	log.Infof("Collecting tests:")
+
+	testInventoryMap := map[string]assembly.InventoryModel{}
+	testInventories := []assembly.InventoryModel{}
+	foundTestCount := 0
+	for _, testProjectName := range pairing.TestProjectNames(testProjectOutputMap, solutionOrder) {
+		testAssembly, err := assembly.Open(testProjectOutputMap[testProjectName].Output.Pth)
+		if err != nil {
+			log.Warnf("Failed to read test assembly, error: %s", err)
+			continue
+		}
+
+		inventory := testAssembly.TestInventory()
+		testInventoryMap[testProjectName] = inventory
+		testInventories = append(testInventories, inventory)
+		foundTestCount += inventory.TestCount()
+
+		log.Printf("- %s: %d tests in %d fixtures", testProjectName, inventory.TestCount(), len(inventory.Fixtures))
+	}
+
+	if len(testInventories) > 0 {
+		testInventoryPth := filepath.Join(configs.DeployDir, "test_inventory.json")
+		if err := exportTestInventory(testInventoryPth, testInventories); err != nil {
+			log.Warnf("Failed to export test inventory, error: %s", err)
+		} else {
+			log.Donef("Test inventory is available in (%s) environment variable", "BITRISE_XAMARIN_TEST_INVENTORY_PATH")
+		}
+
+		if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_FOUND_COUNT", strconv.Itoa(foundTestCount)); err != nil {
+			log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_FOUND_COUNT", err)
+		}
+	}
+	// ---
+
+	//
+	// Test Cloud submit
+	solutionDir := filepath.Dir(configs.XamarinSolution)
+	pattern := filepath.Join(solutionDir, "packages/Xamarin.UITest.*/tools/test-cloud.exe")
+	testClouds, err := filepath.Glob(pattern)
+	if err != nil {
+		failf(failure.ReasonToolNotFound, "Failed to find test-cloud.exe path with pattern (%s), error: %s", pattern, err)
+	}
+	if len(testClouds) == 0 {
+		failf(failure.ReasonToolNotFound, "No test-cloud.exe found path with pattern (%s)", pattern)
+	}
+
+	// Xamarin.UITest version
+	fmt.Println()
+	log.Infof("Checking Xamarin.UITest versions:")
+
+	submittedTestProjects := map[string]bool{}
+	for _, pair := range pairs {
+		submittedTestProjects[pair.TestProject] = true
+	}
+
+	uiTestVersions, err := xamarinUITestVersions(configs.XamarinSolution, solutionOrder, submittedTestProjects)
+	if err != nil {
+		failf(failure.ReasonInputError, "Failed to read Xamarin.UITest versions, error: %s", err)
+	}
+
+	testCloudPth, testCloudVersion := selectTestCloud(testClouds, uiTestVersions)
+	log.Printf("test-cloud.exe: %s", testCloudPth)
+
+	if testCloudVersion == "" {
+		log.Warnf("Failed to determine Xamarin.UITest version of test-cloud.exe (%s)", testCloudPth)
+	} else {
+		mismatch := false
+		rows := [][]string{{"project", "Xamarin.UITest", "test-cloud.exe"}}
+		for _, uiTestVersion := range uiTestVersions {
+			version := uiTestVersion.version
+			if version == "" {
+				if uiTestVersion.referenced != "" {
+					log.Warnf("Failed to resolve Xamarin.UITest version (%s) of project (%s)", uiTestVersion.referenced, uiTestVersion.projectName)
+				}
+				version = "unknown"
+			} else if !nuget.MatchVersion(version, testCloudVersion) {
+				mismatch = true
+			}
+
+			rows = append(rows, []string{uiTestVersion.projectName, version, testCloudVersion})
+		}
+
+		for _, line := range formatTable(rows) {
+			log.Printf(line)
+		}
+
+		if mismatch {
+			failf(failure.ReasonInputError, "Xamarin.UITest version of the test projects does not match the test-cloud.exe version (%s)", testCloudVersion)
+		}
+		log.Donef("Xamarin.UITest versions match")
+	}
+	// ---
+
+	testCloud, err := testcloud.NewModel(testCloudPth)
+	if err != nil {
+		failf(failure.ReasonToolNotFound, "Failed to create test cloud model, error: %s", err)
+	}
+
+	testCloud.SetAPIKey(configs.APIKey)
+	testCloud.SetUser(configs.User)
+	testCloud.SetIsAsyncJSON(configs.IsAsync == "yes")
+
+	// If test cloud runs in asnyc mode test result will not be saved into file
+	resultLogPth := filepath.Join(configs.DeployDir, "TestResult.xml")
+	if configs.IsAsync != "yes" {
+		testCloud.SetNunitXMLPth(resultLogPth)
+	}
+
+	// Parallelization
+	if configs.Parallelization != "none" {
+		parallelization, err := testcloud.ParseParallelization(configs.Parallelization)
+		if err != nil {
+			failf(failure.ReasonInputError, "Failed to parse parallelization, error: %s", err)
+		}
+
+		testCloud.SetParallelization(parallelization)
+	}
+	// ---
+
+	// Custom Options
+	customOptions := []string{}
+	if configs.CustomOptions != "" {
+		options, err := shellquote.Split(runoptions.StripComments(configs.CustomOptions))
+		if err != nil {
+			failf(failure.ReasonInputError, "Failed to split params (%s), error: %s", runoptions.RedactCustomOptions(configs.CustomOptions), err)
+		}
+
+		unknownOptions, err := runoptions.ValidateCustomOptions(options, stepFlags(configs))
+		if err != nil {
+			failf(failure.ReasonInputError, "Issue with input: CustomOptions - %s", err)
+		}
+		for _, option := range unknownOptions {
+			log.Warnf("Unknown test-cloud.exe option in other_parameters: %s, passing it as it is", option)
+		}
+
+		customOptions = options
+	}
+
+	typedFilters := append(testfilter.Split(testfilter.KindCategory, configs.Categories), testfilter.Split(testfilter.KindFixture, configs.Fixtures)...)
+	customOptions = append(customOptions, testfilter.Options(typedFilters)...)
+
+	testParams, err := runoptions.ParseTestParams(configs.TestParams)
+	if err != nil {
+		failf(failure.ReasonInputError, "Failed to parse test params, error: %s", err)
+	}
+	testCloud.SetTestParams(testParams)
+
+	locales, err := runoptions.ParseLocales(configs.Locales)
+	if err != nil {
+		failf(failure.ReasonInputError, "Failed to parse locales, error: %s", err)
+	}
+	// without locales the submission is not repeated
+	submissionLocales := locales
+	if len(submissionLocales) == 0 {
+		submissionLocales = []string{""}
+	}
+	// ---
+
+	// Test filters
+	if filters := testfilter.Parse(customOptions); len(filters) > 0 && len(testInventories) > 0 {
+		fmt.Println()
+		log.Infof("Validating test filters:")
+
+		results := testfilter.Validate(filters, testInventories)
+		for _, result := range results {
+			log.Printf("- %s %s: %d tests", result.Filter.Kind, result.Filter.Value, result.TestCount)
+		}
+
+		if err := testfilter.Error(results); err != nil {
+			failf(failure.ReasonInputError, "Test filters do not match any test:\n%s", err)
+		}
+	}
+	// ---
+
+	// Artifacts
+	resultLog := ""
+	submissions := []submissionModel{}
+	pairResults := []pairResultModel{}
+
+	// Assembly dirs
+	assemblyDirs := map[string]string{}
+	for _, pair := range pairs {
+		testProjectOutput := testProjectOutputMap[pair.TestProject]
+		if _, ok := assemblyDirs[pair.TestProject]; ok {
+			continue
+		}
+
+		fmt.Println()
+		log.Infof("Checking test assembly dependencies: %s", testProjectOutput.Output.Pth)
+
+		assemblyDir := filepath.Dir(testProjectOutput.Output.Pth)
+		dependencies, err := assembly.Dependencies(testProjectOutput.Output.Pth)
+		if err != nil {
+			log.Warnf("Failed to resolve test assembly dependencies, error: %s", err)
+		} else {
+			log.Printf("required files: %d", len(dependencies.Files))
+
+			for _, unreadable := range dependencies.Unreadable {
+				log.Warnf("Failed to read the references of %s, skipping its dependencies, error: %s", filepath.Base(unreadable.Pth), unreadable.Err)
+			}
+
+			if len(dependencies.Missing) > 0 {
+				log.Warnf("Referenced assemblies missing from the assembly dir (%s):", assemblyDir)
+				for _, missing := range dependencies.Missing {
+					log.Warnf("- %s (%s), referred by: %s", missing.Reference.Name, missing.Reference.Version, missing.ReferredBy)
+				}
+			} else {
+				log.Donef("All referenced assemblies found")
+			}
+
+			if configs.StageAssemblyDir == "yes" {
+				stagingDir, err := stageAssemblyDir(assemblyDir, dependencies.Files)
+				if err != nil {
+					failf(failure.ReasonArtifactNotFound, "Failed to stage assembly dir, error: %s", err)
+				}
+
+				originalSize, err := dirSize(assemblyDir)
+				if err != nil {
+					log.Warnf("Failed to calculate assembly dir size, error: %s", err)
+				}
+				stagedSize, err := dirSize(stagingDir)
+				if err != nil {
+					log.Warnf("Failed to calculate staged assembly dir size, error: %s", err)
+				}
+
+				log.Printf("staged assembly dir: %s", stagingDir)
+				log.Donef("Upload size: %s -> %s (saved %s)", formatSize(originalSize), formatSize(stagedSize), formatSize(originalSize-stagedSize))
+
+				assemblyDir = stagingDir
+			}
+		}
+
+		assemblyDirs[pair.TestProject] = assemblyDir
+	}
+	// ---
+
+	for _, pair := range pairs {
+		testProjectName, projectName := pair.TestProject, pair.AppProject
+		testProjectOutput := testProjectOutputMap[testProjectName]
+		assemblyDir := assemblyDirs[testProjectName]
+
+		projectOutput, ok := projectOutputMap[projectName]
+		if !ok {
+			submissions = append(submissions, planFailure(configs.FailFast == "yes", pair, failure.ReasonArtifactNotFound, "No output generated for app project: %s", projectName))
+			continue
+		}
+
+		ipaPth := ""
+		dsymPth := ""
+		for _, output := range projectOutput.Outputs {
+			if output.OutputType == constants.OutputTypeIPA {
+				ipaPth = output.Pth
+			}
+
+			if output.OutputType == constants.OutputTypeDSYM {
+				dsymPth = output.Pth
+			}
+		}
+
+		if ipaPth == "" {
+			submissions = append(submissions, planFailure(configs.FailFast == "yes", pair, failure.ReasonArtifactNotFound, "No ipa generated for project: %s", projectName))
+			continue
+		}
+		if containsOption(customOptions, "--dsym") {
+			// the dSYM given in other_parameters is submitted instead of the generated one
+			dsymPth = ""
+		} else if dsymPth == "" {
+			log.Warnf("No dsym generated for project: %s", projectName)
+		}
+
+		// the app executable is read once for the preflight check and the dSYM verification
+		var app *ipa.App
+		var appErr error
+		if configs.PreflightCheck == "yes" || dsymPth != "" {
+			app, appErr = ipa.ReadApp(ipaPth)
+		}
+
+		if configs.PreflightCheck == "yes" {
+			fmt.Println()
+			log.Infof("Preflight check: %s", ipaPth)
+
+			if appErr != nil {
+				submissions = append(submissions, planFailure(configs.FailFast == "yes", pair, failure.ReasonBuildFailure, "Preflight check failed, error: %s", appErr))
+				continue
+			}
+
+			preflight, err := ipa.Preflight(app)
+			if err != nil {
+				submissions = append(submissions, planFailure(configs.FailFast == "yes", pair, failure.ReasonBuildFailure, "Preflight check failed, error: %s", err))
+				continue
+			}
+
+			log.Printf("executable: %s", preflight.Executable)
+			log.Printf("architectures: %s", strings.Join(preflight.Archs, ", "))
+			log.Donef("Device build with Test Cloud agent linked")
+
+			fmt.Println()
+			log.Infof("Provisioning profile:")
+
+			provisioningProfile, err := profile.NewFromContent(app.EmbeddedProfile)
+			if err != nil {
+				submissions = append(submissions, planFailure(configs.FailFast == "yes", pair, failure.ReasonBuildFailure, "Failed to read embedded provisioning profile, error: %s", err))
+				continue
+			}
+
+			log.Printf("- Name: %s", provisioningProfile.Name)
+			log.Printf("- Team: %s (%s)", provisioningProfile.TeamName, provisioningProfile.TeamID)
+			log.Printf("- Type: %s", provisioningProfile.Type)
+			log.Printf("- ExpirationDate: %s", provisioningProfile.ExpirationDate)
+			log.Printf("- Entitlements:")
+			for _, entitlement := range provisioningProfile.EntitlementList() {
+				log.Printf("  %s", entitlement)
+			}
+
+			if err := provisioningProfile.Validate(time.Now()); err != nil {
+				submissions = append(submissions, planFailure(configs.FailFast == "yes", pair, failure.ReasonBuildFailure, "Invalid provisioning profile: %s", err))
+				continue
+			}
+		}
+
+		if dsymPth != "" {
+			fmt.Println()
+			log.Infof("Verifying dSYM: %s", dsymPth)
+
+			if executableUUIDs, dsymUUIDs, err := readExecutableAndDSYMUUIDs(app, appErr, dsymPth); err != nil {
+				if configs.DSYMMismatch == "fail" {
+					submissions = append(submissions, planFailure(configs.FailFast == "yes", pair, failure.ReasonBuildFailure, "Failed to read UUIDs, dSYM can not be verified, error: %s", err))
+					continue
+				}
+
+				log.Warnf("Failed to read UUIDs, dSYM can not be verified, error: %s", err)
+				log.Warnf("Submitting without dSYM")
+				dsymPth = ""
+			} else if err := dsym.Verify(executableUUIDs, dsymUUIDs); err != nil {
+				if configs.DSYMMismatch == "fail" {
+					submissions = append(submissions, planFailure(configs.FailFast == "yes", pair, failure.ReasonBuildFailure, "%s", err))
+					continue
+				}
+
+				log.Warnf("%s", err)
+				log.Warnf("Submitting without dSYM")
+				dsymPth = ""
+			} else {
+				log.Donef("dSYM matches the app executable")
+			}
+		}
+
+		options := append([]string{}, customOptions...)
+		if pairFilters := pair.Filters(); len(pairFilters) > 0 {
+			if inventory, ok := testInventoryMap[testProjectName]; ok {
+				fmt.Println()
+				log.Infof("Validating test filters of (%s):", testProjectName)
+
+				results := testfilter.Validate(pairFilters, []assembly.InventoryModel{inventory})
+				for _, result := range results {
+					log.Printf("- %s %s: %d tests", result.Filter.Kind, result.Filter.Value, result.TestCount)
+				}
+
+				if err := testfilter.Error(results); err != nil {
+					submissions = append(submissions, planFailure(configs.FailFast == "yes", pair, failure.ReasonInputError, "Test filters do not match any test:\n%s", err))
+					continue
+				}
+			}
+
+			options = append(options, testfilter.Options(pairFilters)...)
+		}
+
+		bundleVersion := ""
+		minimumOSVersion := ""
+		deviceFamilies := []int64{}
+		fmt.Println()
+		log.Infof("App metadata:")
+
+		if metadata, err := ipa.ReadMetadata(ipaPth); err != nil {
+			log.Warnf("Failed to read app metadata, error: %s", err)
+		} else {
+			log.Printf("- BundleID: %s", metadata.BundleID)
+			log.Printf("- DisplayName: %s", metadata.DisplayName)
+			log.Printf("- ShortVersion: %s", metadata.ShortVersion)
+			log.Printf("- BuildNumber: %s", metadata.BuildNumber)
+			log.Printf("- MinimumOSVersion: %s", metadata.MinimumOSVersion)
+
+			exportAppMetadata(metadata)
+			bundleVersion = metadata.BuildNumber
+			minimumOSVersion = metadata.MinimumOSVersion
+			deviceFamilies = metadata.DeviceFamilies
+
+			if configs.AppNameFromIPA == "yes" && metadata.DisplayName != "" && !containsOption(customOptions, "--app-name") {
+				options = append(options, "--app-name", metadata.DisplayName)
+			}
+		}
+
+		// Submit
+		fmt.Println()
+		log.Infof("Testing (%s) against (%s)", testProjectName, projectName)
+		log.Printf("test dll: %s", testProjectOutput.Output.Pth)
+		log.Printf("ipa: %s", ipaPth)
+		log.Printf("dsym: %s", dsymPth)
+
+		deviceSelectionList := configs.Devices
+		if pair.Devices != "" {
+			deviceSelectionList = pair.Devices
+			log.Printf("devices: %s", deviceSelectionList)
+		}
+		deviceSelections, err := devices.ParseSelections(deviceSelectionList)
+		if err != nil {
+			submissions = append(submissions, planFailure(configs.FailFast == "yes", pair, failure.ReasonInputError, "Failed to parse device selections, error: %s", err))
+			continue
+		}
+
+		if len(deviceCatalog) > 0 {
+			for _, deviceSelection := range deviceSelections {
+				deviceSet, ok := deviceCatalog.DeviceSet(deviceSelection.ID)
+				if !ok {
+					log.Warnf("Device selection (%s) not found in the device catalog", deviceSelection.ID)
+					continue
+				}
+
+				name := deviceSelection.Label
+				if deviceSet.Name != "" {
+					name = fmt.Sprintf("%s (%s)", deviceSet.Name, deviceSelection.Label)
+				}
+				log.Printf("devices of %s:", name)
+				for _, device := range deviceSet.Devices {
+					log.Printf("- %s", device)
+				}
+
+				for _, incompatibility := range deviceSet.Incompatibilities(minimumOSVersion, deviceFamilies) {
+					log.Warnf("%s can not install the app: %s", incompatibility.Device, incompatibility.Reason)
+				}
+			}
+		}
+
+		seriesTemplate := configs.Series
+		if pair.Series != "" {
+			seriesTemplate = pair.Series
+		}
+		testSeries, err := series.Render(seriesTemplate, series.DataModel{
+			Branch:        os.Getenv("BITRISE_GIT_BRANCH"),
+			PRNumber:      os.Getenv("BITRISE_PULL_REQUEST"),
+			BuildNumber:   os.Getenv("BITRISE_BUILD_NUMBER"),
+			BundleVersion: bundleVersion,
+			Configuration: configs.XamarinConfiguration,
+		})
+		if err != nil {
+			submissions = append(submissions, planFailure(configs.FailFast == "yes", pair, failure.ReasonInputError, "Failed to render series, error: %s", err))
+			continue
+		}
+		if testSeries != configs.Series {
+			log.Printf("series: %s", testSeries)
+		}
+
+		// the same build is submitted to every device selection, in every locale
+		for _, deviceSelection := range deviceSelections {
+			// selections are labelled in the results, if there are more of them or they are labelled explicitly
+			deviceLabel := ""
+			if len(deviceSelections) > 1 || deviceSelection.Label != deviceSelection.ID {
+				deviceLabel = deviceSelection.Label
+			}
+
+			for _, locale := range submissionLocales {
+				// locales are labelled in the results, if there are more of them
+				localeLabel := ""
+				if len(locales) > 1 {
+					localeLabel = locale
+				}
+
+				// every submission gets its own copy of the test cloud model
+				submissionTestCloud := *testCloud
+				submissionTestCloud.SetDevices(deviceSelection.ID)
+				submissionTestCloud.SetSeries(testSeries)
+				submissionTestCloud.SetAssemblyDir(assemblyDir)
+				submissionTestCloud.SetIPAPth(ipaPth)
+				submissionTestCloud.SetDSYMPth(dsymPth)
+				submissionTestCloud.SetLocale(locale)
+				submissionTestCloud.SetCustomOptions(options...)
+
+				submissionResultLogPth := resultLogPth
+				if len(pairs) > 1 || len(deviceSelections) > 1 || len(locales) > 1 {
+					resultLogName := fmt.Sprintf("TestResult_%s_%s", fileNameComponent(testProjectName), fileNameComponent(projectName))
+					if deviceLabel != "" {
+						resultLogName += "_" + fileNameComponent(deviceLabel)
+					}
+					if localeLabel != "" {
+						resultLogName += "_" + fileNameComponent(localeLabel)
+					}
+					submissionResultLogPth = filepath.Join(configs.DeployDir, resultLogName+".xml")
+				}
+				if configs.IsAsync != "yes" {
+					submissionTestCloud.SetNunitXMLPth(submissionResultLogPth)
+				}
+
+				submissions = append(submissions, submissionModel{
+					pair:              pair,
+					deviceLabel:       deviceLabel,
+					deviceSelectionID: deviceSelection.ID,
+					locale:            localeLabel,
+					filters:           testfilter.Parse(options),
+					testCloud:         submissionTestCloud,
+					dsymPth:           dsymPth,
+					resultLogPth:      submissionResultLogPth,
+				})
+			}
+		}
+	}
+	// ---
+
+	//
+	// Device minutes
+	if configs.DeviceCatalog != "" {
+		fmt.Println()
+		log.Infof("Estimating device minutes:")
+
+		testDurations := estimate.HistoryModel{}
+		if configs.TestDurations != "" {
+			history, err := estimate.ReadHistory(configs.TestDurations)
+			if err != nil {
+				failf(failure.ReasonInputError, "Failed to read test durations, error: %s", err)
+			}
+			testDurations = history
+		}
+
+		deviceMinutes := 0.0
+		unknownTestCount := 0
+		notEstimated := []string{}
+		rows := [][]string{{"test run", "tests", "devices", "duration", "device minutes"}}
+		for _, submission := range submissions {
+			if submission.planErr != nil {
+				continue
+			}
+
+			deviceSet, ok := deviceCatalog.DeviceSet(submission.deviceSelectionID)
+			if !ok {
+				log.Warnf("Device selection (%s) of (%s) not found in the device catalog", submission.deviceSelectionID, submission.name())
+				notEstimated = append(notEstimated, submission.name())
+				continue
+			}
+
+			inventory, ok := testInventoryMap[submission.pair.TestProject]
+			if !ok {
+				notEstimated = append(notEstimated, submission.name())
+				continue
+			}
+
+			runEstimate := estimate.Estimate(inventory, submission.filters, testDurations, len(deviceSet.Devices))
+			deviceMinutes += runEstimate.DeviceMinutes()
+			unknownTestCount += runEstimate.UnknownTestCount
+
+			rows = append(rows, []string{
+				submission.name(),
+				strconv.Itoa(runEstimate.TestCount),
+				strconv.Itoa(runEstimate.DeviceCount),
+				(runEstimate.Duration / time.Second * time.Second).String(),
+				strconv.FormatFloat(runEstimate.DeviceMinutes(), 'f', 1, 64),
+			})
+		}
+
+		for _, line := range formatTable(rows) {
+			log.Printf(line)
+		}
+
+		if unknownTestCount > 0 {
+			log.Warnf("%d tests have no duration history, estimated with the mean test duration", unknownTestCount)
+		}
+		if len(notEstimated) > 0 {
+			log.Warnf("The estimate does not include: %s", strings.Join(notEstimated, ", "))
+		}
+
+		formattedDeviceMinutes := strconv.FormatFloat(deviceMinutes, 'f', 1, 64)
+		log.Donef("Estimated device minutes: %s", formattedDeviceMinutes)
+
+		if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_ESTIMATED_DEVICE_MINUTES", formattedDeviceMinutes); err != nil {
+			log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_ESTIMATED_DEVICE_MINUTES", err)
+		}
+
+		if configs.DeviceMinutesBudget != "" {
+			budget, err := strconv.ParseFloat(configs.DeviceMinutesBudget, 64)
+			if err != nil {
+				failf(failure.ReasonInputError, "Failed to parse device minutes budget (%s), error: %s", configs.DeviceMinutesBudget, err)
+			}
+
+			if err := estimate.CheckBudget(deviceMinutes, budget, notEstimated); err != nil {
+				if configs.IgnoreDeviceMinutesBudget != "yes" {
+					failf(failure.ReasonInputError, "Device minutes budget: %s", err)
+				}
+				log.Warnf("Device minutes budget: %s, submitting anyway", err)
+			} else {
+				log.Donef("Within the budget (%s)", configs.DeviceMinutesBudget)
+			}
+		}
+	}
+	// ---
+
+	//
+	// Submit
+	concurrentSubmissions, err := strconv.Atoi(configs.ConcurrentSubmissions)
+	if err != nil {
+		failf(failure.ReasonInputError, "Failed to parse concurrent submissions (%s), error: %s", configs.ConcurrentSubmissions, err)
+	}
+	submittable := 0
+	for _, submission := range submissions {
+		if submission.planErr == nil {
+			submittable++
+		}
+	}
+	if concurrentSubmissions > submittable {
+		concurrentSubmissions = submittable
+	}
+
+	heartbeatInterval, err := strconv.Atoi(configs.HeartbeatInterval)
+	if err != nil {
+		failf(failure.ReasonInputError, "Failed to parse heartbeat interval (%s), error: %s", configs.HeartbeatInterval, err)
+	}
+
+	// results are processed in submission order, as soon as all the preceding results are processed
+	submissionResults := make([]chan submissionResultModel, len(submissions))
+	for i := range submissionResults {
+		submissionResults[i] = make(chan submissionResultModel, 1)
+	}
+
+	var stopped int32
+	jobs := make(chan int)
+	for worker := 0; worker < concurrentSubmissions; worker++ {
+		go func() {
+			for i := range jobs {
+				if atomic.LoadInt32(&stopped) == 1 {
+					submissionResults[i] <- submissionResultModel{skipped: true}
+					continue
+				}
+
+				prefix := ""
+				if concurrentSubmissions > 1 {
+					prefix = fmt.Sprintf("[%s] ", submissions[i].name())
+				}
+
+				result := submitTestRun(submissions[i], configs.IsAsync == "yes", time.Duration(heartbeatInterval)*time.Second, prefix)
+				if result.failed() && configs.FailFast == "yes" {
+					atomic.StoreInt32(&stopped, 1)
+				}
+				submissionResults[i] <- result
+			}
+		}()
+	}
+	go func() {
+		for i, submission := range submissions {
+			if submission.planErr == nil {
+				jobs <- i
+			}
+		}
+		close(jobs)
+	}()
+
+	failedResultLog := ""
+	phaseTimings := []string{}
+	for i, submission := range submissions {
+		pair := submission.pair
+		dsymPth := submission.dsymPth
+
+		if submission.planErr != nil {
+			pairResults = append(pairResults, pairResultModel{pair: pair, failed: true, reason: submission.planReason, message: submission.planErr.Error()})
+			continue
+		}
+
+		result := <-submissionResults[i]
+
+		if result.skipped {
+			pairResults = append(pairResults, pairResultModel{pair: pair, deviceLabel: submission.deviceLabel, locale: submission.locale, skipped: true})
+			continue
+		}
+
+		if len(submissions) > 1 {
+			fmt.Println()
+			log.Infof("Results of (%s):", submission.name())
+		}
+
+		if len(result.timings) > 0 {
+			fmt.Println()
+			log.Infof("Phase timings:")
+			for _, timing := range result.timings {
+				log.Printf("- %s: %s", timing.Phase, timing.Duration/time.Second*time.Second)
+			}
+
+			for _, line := range strings.Split(progress.FormatTimings(result.timings), "\n") {
+				if len(submissions) > 1 {
+					// the phases of every submission are exported, keyed by the name of the submission
+					line = submission.name() + ": " + line
+				}
+				phaseTimings = append(phaseTimings, line)
+			}
+		}
+
+		// If test cloud runs in asnyc mode test result will not be saved into file
+		hasTestResult := false
+		if configs.IsAsync != "yes" {
+			testLog, logErr := testResultLogContent(submission.resultLogPth)
+			if logErr != nil {
+				log.Warnf("Failed to read test result, error: %s", logErr)
+			}
+			resultLog = testLog
+			hasTestResult = logErr == nil
+		}
+
+		if result.err != nil {
+			log.Errorf("Submit failed, error: %s", result.err)
+			for _, failure := range result.failures {
+				log.Errorf("- %s", failure)
+			}
+			printHints(result.output)
+
+			if resultLog != "" && dsymPth != "" {
+				resultLog = symbolicateTestResult(dsymPth, submission.resultLogPth, resultLog)
+			}
+			if failedResultLog == "" {
+				failedResultLog = resultLog
+			}
+
+			// the tests ran, if test-cloud.exe wrote the NUnit result
+			reason := failure.ReasonTestFailures
+			pairResultLogPth := submission.resultLogPth
+			if !hasTestResult {
+				reason = failure.Categorize(append(append([]string{}, result.failures...), result.output...))
+				pairResultLogPth = ""
+			}
+
+			pairResults = append(pairResults, pairResultModel{pair: pair, deviceLabel: submission.deviceLabel, locale: submission.locale, failed: true, reason: reason, message: result.err.Error(), resultLogPth: pairResultLogPth})
+			continue
+		}
+		// ---
+
+		testRunID := ""
+
+		if configs.IsAsync == "yes" {
+			fmt.Println()
+			log.Infof("Preocessing json result:")
+
+			if result.jsonErr != nil {
+				log.Errorf("Failed to unmarshal result, error: %s", result.jsonErr)
+			} else if result.jsonResult != nil {
+				errorMessages := result.jsonResult.ErrorMessages
+				if len(errorMessages) > 0 && dsymPth != "" {
+					errorMessages = symbolicateMessages(dsymPth, errorMessages)
+				}
+
+				for _, errorMsg := range errorMessages {
+					log.Errorf(errorMsg)
+				}
+				if len(errorMessages) > 0 {
+					printHints(append(append([]string{}, errorMessages...), result.output...))
+				}
+
+				if len(errorMessages) > 0 {
+					if failedResultLog == "" {
+						failedResultLog = resultLog
+					}
+
+					reason := failure.Categorize(errorMessages)
+					pairResults = append(pairResults, pairResultModel{pair: pair, deviceLabel: submission.deviceLabel, locale: submission.locale, failed: true, reason: reason, message: errorMessages[0]})
+					continue
+				}
+
+				testRunID = result.jsonResult.TestRunID
+
+				if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_TO_RUN_ID", testRunID); err != nil {
+					log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_TO_RUN_ID", err)
+				}
+
+				log.Donef("TestRunId (%s) is available in (%s) environment variable", testRunID, "BITRISE_XAMARIN_TEST_TO_RUN_ID")
+			}
+		}
+
+		pairResultLogPth := ""
+		if hasTestResult {
+			pairResultLogPth = submission.resultLogPth
+		}
+
+		pairResults = append(pairResults, pairResultModel{pair: pair, deviceLabel: submission.deviceLabel, locale: submission.locale, testRunID: testRunID, resultLogPth: pairResultLogPth})
+	}
+	// ---
+
+	//
+	// Summary
+	failedPairs := []string{}
+	failureReasons := []failure.Reason{}
+	failureDetails := []string{}
+	for _, pairResult := range pairResults {
+		if pairResult.failed {
+			failedPairs = append(failedPairs, pairResult.name())
+			failureReasons = append(failureReasons, pairResult.reason)
+			failureDetails = append(failureDetails, fmt.Sprintf("%s: %s: %s", pairResult.name(), pairResult.reason, pairResult.message))
+		}
+	}
+
+	if len(pairResults) > 1 || len(failedPairs) > 0 {
+		fmt.Println()
+		log.Infof("Summary:")
+
+		rows := [][]string{{"test project", "app project", "devices", "locale", "status", "details"}}
+		for _, pairResult := range pairResults {
+			status, details := "succeeded", pairResult.testRunID
+			if pairResult.failed {
+				status, details = "failed", pairResult.message
+			} else if pairResult.skipped {
+				status = "skipped"
+			}
+			rows = append(rows, []string{pairResult.pair.TestProject, pairResult.pair.AppProject, pairResult.deviceLabel, pairResult.locale, status, details})
+		}
+
+		for _, line := range formatTable(rows) {
+			log.Printf(line)
+		}
+	}
+
+	if len(phaseTimings) > 0 {
+		if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_PHASE_TIMINGS", strings.Join(phaseTimings, "\n")); err != nil {
+			log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_PHASE_TIMINGS", err)
+		}
+	}
+
+	deviceLabels := []string{}
+	deviceResults := map[string]string{}
+	deviceRunIDs := map[string][]string{}
+	deviceResultLogPths := map[string][]string{}
+	for _, pairResult := range pairResults {
+		if pairResult.deviceLabel == "" {
+			continue
+		}
+
+		if pairResult.testRunID != "" {
+			deviceRunIDs[pairResult.deviceLabel] = append(deviceRunIDs[pairResult.deviceLabel], pairResult.testRunID)
+		}
+		if pairResult.resultLogPth != "" {
+			deviceResultLogPths[pairResult.deviceLabel] = append(deviceResultLogPths[pairResult.deviceLabel], pairResult.resultLogPth)
+		}
+
+		status, ok := deviceResults[pairResult.deviceLabel]
+		if !ok {
+			deviceLabels = append(deviceLabels, pairResult.deviceLabel)
+			status = "succeeded"
+		}
+		if pairResult.failed {
+			status = "failed"
+		} else if pairResult.skipped && status == "succeeded" {
+			status = "skipped"
+		}
+		deviceResults[pairResult.deviceLabel] = status
+	}
+
+	if len(deviceLabels) > 0 {
+		lines := []string{}
+		for _, label := range deviceLabels {
+			lines = append(lines, fmt.Sprintf("%s: %s", label, deviceResults[label]))
+		}
+
+		if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_DEVICE_RESULTS", strings.Join(lines, "\n")); err != nil {
+			log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_DEVICE_RESULTS", err)
+		}
+
+		exportDeviceValues("BITRISE_XAMARIN_TEST_DEVICE_RUN_IDS", deviceLabels, deviceRunIDs)
+		exportDeviceValues("BITRISE_XAMARIN_TEST_DEVICE_FULL_RESULTS_PATHS", deviceLabels, deviceResultLogPths)
+	}
+
+	if len(failedPairs) > 0 {
+		if err := tools.ExportEnvironmentWithEnvman("BITRISE_XAMARIN_TEST_FAILED_PAIRS", strings.Join(failedPairs, "\n")); err != nil {
+			log.Warnf("Failed to export environment: %s, error: %s", "BITRISE_XAMARIN_TEST_FAILED_PAIRS", err)
+		}
+
+		exportTestResult("failed", failedResultLog)
+		exportFailure(failure.Combine(failureReasons), strings.Join(failureDetails, "\n"))
+		log.Errorf("%d of %d test runs failed", len(failedPairs), len(pairResults))
+		os.Exit(1)
+	}
+	// ---
+
+	exportTestResult("succeeded", resultLog)
+}
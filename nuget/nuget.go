@@ -0,0 +1,191 @@
+package nuget
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type packagesConfigModel struct {
+	Packages []struct {
+		ID      string `xml:"id,attr"`
+		Version string `xml:"version,attr"`
+	} `xml:"package"`
+}
+
+type projectModel struct {
+	ItemGroups []struct {
+		PackageReferences []struct {
+			Include string `xml:"Include,attr"`
+			Update  string `xml:"Update,attr"`
+			Version string `xml:"Version,attr"`
+			// Version may also be defined as a child element
+			VersionElement string `xml:"Version"`
+		} `xml:"PackageReference"`
+		References []struct {
+			Include  string `xml:"Include,attr"`
+			HintPath string `xml:"HintPath"`
+		} `xml:"Reference"`
+	} `xml:"ItemGroup"`
+}
+
+// PackageVersion returns the version of the package referenced by the project,
+// read from the packages.config next to the project, the project's PackageReference items
+// or the HintPath of the project's assembly references, in this order.
+func PackageVersion(projectPth, packageID string) (string, error) {
+	packagesConfigPth := filepath.Join(filepath.Dir(projectPth), "packages.config")
+	if content, err := ioutil.ReadFile(packagesConfigPth); err == nil {
+		var packagesConfig packagesConfigModel
+		if err := xml.Unmarshal(content, &packagesConfig); err != nil {
+			return "", fmt.Errorf("failed to parse (%s), error: %s", packagesConfigPth, err)
+		}
+
+		for _, pkg := range packagesConfig.Packages {
+			if strings.EqualFold(pkg.ID, packageID) {
+				return pkg.Version, nil
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	content, err := ioutil.ReadFile(projectPth)
+	if err != nil {
+		return "", err
+	}
+
+	var project projectModel
+	if err := xml.Unmarshal(content, &project); err != nil {
+		return "", fmt.Errorf("failed to parse (%s), error: %s", projectPth, err)
+	}
+
+	for _, itemGroup := range project.ItemGroups {
+		for _, reference := range itemGroup.PackageReferences {
+			if !strings.EqualFold(reference.Include, packageID) && !strings.EqualFold(reference.Update, packageID) {
+				continue
+			}
+
+			if reference.Version != "" {
+				return reference.Version, nil
+			}
+			return strings.TrimSpace(reference.VersionElement), nil
+		}
+	}
+
+	for _, itemGroup := range project.ItemGroups {
+		for _, reference := range itemGroup.References {
+			assemblyName := strings.TrimSpace(strings.Split(reference.Include, ",")[0])
+			if !strings.EqualFold(assemblyName, packageID) {
+				continue
+			}
+
+			// HintPath uses windows path separators
+			hintPth := strings.Replace(reference.HintPath, `\`, "/", -1)
+			if version := PackageVersionFromPath(hintPth, packageID); version != "" {
+				return version, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+var versionPattern = regexp.MustCompile(`^\d+(?:\.\d+)*(?:\.\*|-[0-9A-Za-z.-]+)?$|^\*$`)
+
+// ResolveVersion returns the version the referenced version pins the package to,
+// an exact version range ([2.2.4]) is resolved to its version.
+// MSBuild properties ($(UITestVersion)) and version ranges ([2.2,3.0)) can not be resolved,
+// in this case false is returned.
+func ResolveVersion(referenced string) (string, bool) {
+	version := strings.TrimSpace(referenced)
+	if strings.HasPrefix(version, "[") && strings.HasSuffix(version, "]") {
+		version = strings.TrimSpace(version[1 : len(version)-1])
+	}
+
+	if !versionPattern.MatchString(version) {
+		return "", false
+	}
+	return version, true
+}
+
+// PackageVersionFromPath returns the version of the package from a path within
+// the package's directory (for example: packages/Xamarin.UITest.2.2.4/tools/test-cloud.exe).
+func PackageVersionFromPath(pth, packageID string) string {
+	pattern := `(?i)(?:^|/)` + regexp.QuoteMeta(packageID) + `\.(\d+(?:\.\d+)*(?:-[0-9A-Za-z.-]+)?)(?:/|$)`
+	if matches := regexp.MustCompile(pattern).FindStringSubmatch(filepath.ToSlash(pth)); len(matches) == 2 {
+		return matches[1]
+	}
+	return ""
+}
+
+// NormalizeVersion trims the trailing zero components of a version after the third one,
+// so 2.2.4.0 and 2.2.4 are considered as the same version, like NuGet does.
+func NormalizeVersion(version string) string {
+	release := version
+	prerelease := ""
+	if idx := strings.Index(version, "-"); idx != -1 {
+		release, prerelease = version[:idx], version[idx:]
+	}
+
+	components := strings.Split(release, ".")
+	for len(components) > 3 && components[len(components)-1] == "0" {
+		components = components[:len(components)-1]
+	}
+
+	return strings.ToLower(strings.Join(components, ".") + prerelease)
+}
+
+// MatchVersion reports whether the package version satisfies the referenced version,
+// a floating version (2.2.*) matches every version starting with its prefix.
+func MatchVersion(referenced, version string) bool {
+	if idx := strings.Index(referenced, "*"); idx != -1 {
+		return strings.HasPrefix(strings.ToLower(version), strings.ToLower(referenced[:idx]))
+	}
+	return NormalizeVersion(referenced) == NormalizeVersion(version)
+}
+
+// CompareVersions compares the versions by their numeric components,
+// a prerelease version is lower than its release.
+// The result is negative if a is lower than b, positive if a is higher than b and 0 if they are the same.
+func CompareVersions(a, b string) int {
+	aRelease, aPrerelease := splitPrerelease(NormalizeVersion(a))
+	bRelease, bPrerelease := splitPrerelease(NormalizeVersion(b))
+
+	aComponents := strings.Split(aRelease, ".")
+	bComponents := strings.Split(bRelease, ".")
+	for i := 0; i < len(aComponents) || i < len(bComponents); i++ {
+		aComponent, bComponent := 0, 0
+		if i < len(aComponents) {
+			aComponent, _ = strconv.Atoi(aComponents[i])
+		}
+		if i < len(bComponents) {
+			bComponent, _ = strconv.Atoi(bComponents[i])
+		}
+		if aComponent != bComponent {
+			return aComponent - bComponent
+		}
+	}
+
+	switch {
+	case aPrerelease == bPrerelease:
+		return 0
+	case aPrerelease == "":
+		return 1
+	case bPrerelease == "":
+		return -1
+	default:
+		return strings.Compare(aPrerelease, bPrerelease)
+	}
+}
+
+func splitPrerelease(version string) (string, string) {
+	if idx := strings.Index(version, "-"); idx != -1 {
+		return version[:idx], version[idx+1:]
+	}
+	return version, ""
+}
@@ -0,0 +1,100 @@
+package nuget
+
+import "testing"
+
+func TestMatchVersion(t *testing.T) {
+	tests := []struct {
+		referenced string
+		version    string
+		want       bool
+	}{
+		{"2.2.4", "2.2.4", true},
+		{"2.2.4.0", "2.2.4", true},
+		{"2.2.4", "2.2.10", false},
+		{"2.2.*", "2.2.10", true},
+		{"2.2.*", "2.3.0", false},
+		{"2.*", "2.2.4-dev1", true},
+		{"*", "3.0.0", true},
+	}
+
+	for _, test := range tests {
+		if got := MatchVersion(test.referenced, test.version); got != test.want {
+			t.Errorf("MatchVersion(%q, %q): got %t, want %t", test.referenced, test.version, got, test.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"2.2.10", "2.2.4", 1},
+		{"2.2.4", "2.2.10", -1},
+		{"2.2.4.0", "2.2.4", 0},
+		{"2.2.4", "2.2.4.1", -1},
+		{"2.2.4-dev1", "2.2.4", -1},
+		{"2.2.4-dev2", "2.2.4-dev1", 1},
+		{"3.0.0", "2.9.9", 1},
+	}
+
+	for _, test := range tests {
+		got := CompareVersions(test.a, test.b)
+		if (got > 0) != (test.want > 0) || (got < 0) != (test.want < 0) {
+			t.Errorf("CompareVersions(%q, %q): got %d, want sign of %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestPackageVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		projectPth string
+		packageID  string
+		want       string
+	}{
+		{"packages.config", "testdata/PackagesConfig/PackagesConfig.csproj", "Xamarin.UITest", "2.2.4"},
+		{"PackageReference", "testdata/PackageReference/PackageReference.csproj", "Xamarin.UITest", "[2.2.5]"},
+		{"PackageReference version element", "testdata/Property/Property.csproj", "Xamarin.UITest", "$(UITestVersion)"},
+		{"HintPath", "testdata/HintPath/HintPath.csproj", "Xamarin.UITest", "2.2.6"},
+		{"not referenced", "testdata/HintPath/HintPath.csproj", "Xamarin.Forms", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := PackageVersion(test.projectPth, test.packageID)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveVersion(t *testing.T) {
+	tests := []struct {
+		referenced string
+		want       string
+		wantOK     bool
+	}{
+		{"2.2.4", "2.2.4", true},
+		{"[2.2.4]", "2.2.4", true},
+		{" [ 2.2.4 ] ", "2.2.4", true},
+		{"2.2.*", "2.2.*", true},
+		{"2.2.4-dev1", "2.2.4-dev1", true},
+		{"$(UITestVersion)", "", false},
+		{"[2.2,3.0)", "", false},
+		{"(,3.0]", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		got, ok := ResolveVersion(test.referenced)
+		if got != test.want || ok != test.wantOK {
+			t.Errorf("ResolveVersion(%q): got (%q, %t), want (%q, %t)", test.referenced, got, ok, test.want, test.wantOK)
+		}
+	}
+}
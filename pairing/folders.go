@@ -0,0 +1,88 @@
+package pairing
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const solutionFolderTypeID = "2150E333-8FDC-42A3-9474-1A3956D46DE8"
+
+var (
+	solutionFolderPattern = regexp.MustCompile(`^Project\("\{([^}]+)\}"\) = "([^"]*)", "[^"]*", "\{([^}]+)\}"`)
+	nestedProjectPattern  = regexp.MustCompile(`^\{([^}]+)\} = \{([^}]+)\}$`)
+)
+
+// solutionFoldersModel is the solution folder tree of a solution, read from its NestedProjects section.
+type solutionFoldersModel struct {
+	names   map[string]string // Folder ID - folder name map
+	parents map[string]string // Project or folder ID - parent folder ID map
+}
+
+// readSolutionFolders reads the solution folders of the solution,
+// a solution which can not be read is handled as a solution without folders.
+func readSolutionFolders(slnPth string) solutionFoldersModel {
+	folders := solutionFoldersModel{names: map[string]string{}, parents: map[string]string{}}
+
+	file, err := os.Open(slnPth)
+	if err != nil {
+		return folders
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			return
+		}
+	}()
+
+	isNestedProjectsSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, "GlobalSection(NestedProjects)"):
+			isNestedProjectsSection = true
+		case isNestedProjectsSection && line == "EndGlobalSection":
+			isNestedProjectsSection = false
+		case isNestedProjectsSection:
+			if matches := nestedProjectPattern.FindStringSubmatch(line); len(matches) == 3 {
+				folders.parents[strings.ToUpper(matches[1])] = strings.ToUpper(matches[2])
+			}
+		default:
+			if matches := solutionFolderPattern.FindStringSubmatch(line); len(matches) == 4 && strings.EqualFold(matches[1], solutionFolderTypeID) {
+				folders.names[strings.ToUpper(matches[3])] = matches[2]
+			}
+		}
+	}
+
+	return folders
+}
+
+// ancestors returns the solution folders of the project or folder, from the outermost one.
+func (folders solutionFoldersModel) ancestors(id string) []string {
+	ancestors := []string{}
+	seen := map[string]bool{}
+	for parent, ok := folders.parents[strings.ToUpper(id)]; ok && !seen[parent]; parent, ok = folders.parents[parent] {
+		seen[parent] = true
+		ancestors = append([]string{parent}, ancestors...)
+	}
+	return ancestors
+}
+
+// distance returns the number of folder steps between the solution folders of the projects
+// and the name of their innermost common solution folder.
+// It returns false if the projects do not share a solution folder, only the solution root.
+func (folders solutionFoldersModel) distance(a, b string) (int, string, bool) {
+	aAncestors, bAncestors := folders.ancestors(a), folders.ancestors(b)
+
+	common := 0
+	for common < len(aAncestors) && common < len(bAncestors) && aAncestors[common] == bAncestors[common] {
+		common++
+	}
+	if common == 0 {
+		return 0, "", false
+	}
+
+	return len(aAncestors) - common + len(bAncestors) - common, folders.names[aAncestors[common-1]], true
+}
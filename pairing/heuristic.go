@@ -0,0 +1,286 @@
+package pairing
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/plist"
+	"github.com/bitrise-tools/go-xamarin/analyzers/project"
+	"github.com/bitrise-tools/go-xamarin/analyzers/solution"
+	"github.com/bitrise-tools/go-xamarin/constants"
+)
+
+// Confidence ...
+type Confidence string
+
+const (
+	// ConfidenceHigh ...
+	ConfidenceHigh Confidence = "high"
+	// ConfidenceMedium ...
+	ConfidenceMedium Confidence = "medium"
+	// ConfidenceLow ...
+	ConfidenceLow Confidence = "low"
+)
+
+const (
+	bundleIDScore         = 100
+	appBundleScore        = 80
+	namePrefixScore       = 20
+	maxNamePrefixScore    = 40
+	sameFolderScore       = 20
+	nearbyFolderScore     = 10
+	nearbyFolderMaxSteps  = 2
+	highConfidenceScore   = 80
+	mediumConfidenceScore = 40
+)
+
+var (
+	installedAppPattern = regexp.MustCompile(`\.InstalledApp\s*\(\s*"([^"]+)"\s*\)`)
+	appBundlePattern    = regexp.MustCompile(`\.AppBundle\s*\(\s*@?"([^"]+)"\s*\)`)
+)
+
+// InferredModel ...
+type InferredModel struct {
+	Model
+	Score      int
+	Confidence Confidence
+	Reasons    []string
+}
+
+// candidateModel is a scored app project, identified is set if the bundle id, the app bundle
+// or the project name points to the app project, not just the solution folder proximity.
+type candidateModel struct {
+	InferredModel
+	identified bool
+}
+
+type appBundleModel struct {
+	bundleIDs   []string
+	bundleNames []string
+}
+
+// Infer pairs the Xamarin.UITest projects of the solution, which do not refer to any iOS app project,
+// with the most likely iOS app project of the solution.
+// The second return value lists the test projects which could not be paired and why.
+func Infer(sln solution.Model, testProjectNames []string) ([]InferredModel, []string) {
+	appProjects := []project.Model{}
+	for _, proj := range sln.ProjectMap {
+		if proj.SDK == constants.SDKIOS && proj.OutputType == "exe" {
+			appProjects = append(appProjects, proj)
+		}
+	}
+	sort.Slice(appProjects, func(i, j int) bool { return appProjects[i].Name < appProjects[j].Name })
+
+	projectsByName := map[string]project.Model{}
+	for _, proj := range sln.ProjectMap {
+		projectsByName[proj.Name] = proj
+	}
+
+	folders := readSolutionFolders(sln.Pth)
+
+	inferred := []InferredModel{}
+	skipped := []string{}
+	for _, testProjectName := range testProjectNames {
+		testProject, ok := projectsByName[testProjectName]
+		if !ok {
+			skipped = append(skipped, fmt.Sprintf("%s: not found in solution", testProjectName))
+			continue
+		}
+
+		bundle := readAppBundles(filepath.Dir(testProject.Pth))
+		prefix, prefixAppProjects := longestNamePrefix(testProject, appProjects)
+
+		candidates := []InferredModel{}
+		nearbyAppProjects := []string{}
+		for _, appProject := range appProjects {
+			identifiedByPrefix := len(prefixAppProjects) == 1 && prefixAppProjects[0] == appProject.Name
+			candidate := score(testProject, appProject, bundle, folders, identifiedByPrefix)
+			if candidate.identified {
+				candidates = append(candidates, candidate.InferredModel)
+			} else if candidate.Score > 0 {
+				nearbyAppProjects = append(nearbyAppProjects, appProject.Name)
+			}
+		}
+
+		if len(candidates) == 0 {
+			if len(prefixAppProjects) > 1 {
+				skipped = append(skipped, fmt.Sprintf("%s: ambiguous, the name prefix (%s) is shared by (%s)", testProjectName, prefix, strings.Join(prefixAppProjects, ", ")))
+			} else if len(nearbyAppProjects) > 0 {
+				skipped = append(skipped, fmt.Sprintf("%s: only the solution folder of (%s) is nearby, no bundle id or name matches", testProjectName, strings.Join(nearbyAppProjects, ", ")))
+			} else {
+				skipped = append(skipped, fmt.Sprintf("%s: no matching iOS app project", testProjectName))
+			}
+			continue
+		}
+
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+		if len(candidates) > 1 && candidates[0].Score == candidates[1].Score {
+			skipped = append(skipped, fmt.Sprintf("%s: ambiguous, (%s) and (%s) match equally", testProjectName, candidates[0].AppProject, candidates[1].AppProject))
+			continue
+		}
+
+		inferred = append(inferred, candidates[0])
+	}
+
+	return inferred, skipped
+}
+
+// score scores the app project for the test project,
+// the shared name prefix only identifies the app project if no other app project shares as long a prefix.
+func score(testProject, appProject project.Model, bundle appBundleModel, folders solutionFoldersModel, identifiedByPrefix bool) candidateModel {
+	inferred := InferredModel{Model: Model{TestProject: testProject.Name, AppProject: appProject.Name}}
+	identified := false
+
+	if bundleID := appBundleID(appProject); bundleID != "" {
+		for _, id := range bundle.bundleIDs {
+			if id == bundleID {
+				inferred.Score += bundleIDScore
+				identified = true
+				inferred.Reasons = append(inferred.Reasons, fmt.Sprintf("InstalledApp(%s) matches the app's bundle id", id))
+				break
+			}
+		}
+	}
+
+	for _, name := range bundle.bundleNames {
+		if strings.EqualFold(name, appProject.AssemblyName) || strings.EqualFold(name, appProject.Name) {
+			inferred.Score += appBundleScore
+			identified = true
+			inferred.Reasons = append(inferred.Reasons, fmt.Sprintf("AppBundle(%s.app) matches the app's assembly name", name))
+			break
+		}
+	}
+
+	if prefix := commonNamePrefix(testProject.Name, appProject.Name); prefix != "" {
+		prefixScore := namePrefixScore * (strings.Count(prefix, ".") + 1)
+		if prefixScore > maxNamePrefixScore {
+			prefixScore = maxNamePrefixScore
+		}
+		inferred.Score += prefixScore
+		identified = identified || identifiedByPrefix
+		inferred.Reasons = append(inferred.Reasons, fmt.Sprintf("shared name prefix (%s)", prefix))
+	}
+
+	if steps, folder, ok := folders.distance(testProject.ID, appProject.ID); ok {
+		switch {
+		case steps == 0:
+			inferred.Score += sameFolderScore
+			inferred.Reasons = append(inferred.Reasons, fmt.Sprintf("in the same solution folder (%s)", folder))
+		case steps <= nearbyFolderMaxSteps:
+			inferred.Score += nearbyFolderScore
+			inferred.Reasons = append(inferred.Reasons, fmt.Sprintf("in nearby solution folders (under %s)", folder))
+		}
+	}
+
+	switch {
+	case inferred.Score >= highConfidenceScore:
+		inferred.Confidence = ConfidenceHigh
+	case inferred.Score >= mediumConfidenceScore:
+		inferred.Confidence = ConfidenceMedium
+	default:
+		inferred.Confidence = ConfidenceLow
+	}
+
+	return candidateModel{InferredModel: inferred, identified: identified}
+}
+
+// commonNamePrefix returns the dot separated name components shared by the project names.
+func commonNamePrefix(a, b string) string {
+	aComponents := strings.Split(a, ".")
+	bComponents := strings.Split(b, ".")
+
+	shared := []string{}
+	for i := 0; i < len(aComponents) && i < len(bComponents); i++ {
+		if !strings.EqualFold(aComponents[i], bComponents[i]) {
+			break
+		}
+		shared = append(shared, aComponents[i])
+	}
+	return strings.Join(shared, ".")
+}
+
+// longestNamePrefix returns the longest name prefix the test project shares with any of the app projects
+// and the app projects sharing it.
+func longestNamePrefix(testProject project.Model, appProjects []project.Model) (string, []string) {
+	longest := ""
+	names := []string{}
+	for _, appProject := range appProjects {
+		prefix := commonNamePrefix(testProject.Name, appProject.Name)
+		if prefix == "" {
+			continue
+		}
+
+		switch components, longestComponents := strings.Count(prefix, ".")+1, strings.Count(longest, ".")+1; {
+		case longest == "" || components > longestComponents:
+			longest = prefix
+			names = []string{appProject.Name}
+		case components == longestComponents:
+			names = append(names, appProject.Name)
+		}
+	}
+	return longest, names
+}
+
+// appBundleID reads the bundle id from the Info.plist of the app project.
+func appBundleID(appProject project.Model) string {
+	content, err := ioutil.ReadFile(filepath.Join(filepath.Dir(appProject.Pth), "Info.plist"))
+	if err != nil {
+		return ""
+	}
+
+	data, err := plist.NewDataFromContent(content)
+	if err != nil {
+		return ""
+	}
+
+	bundleID, _ := data.GetString("CFBundleIdentifier")
+	return bundleID
+}
+
+// readAppBundles collects the InstalledApp bundle ids and AppBundle app names configured in the test sources.
+func readAppBundles(dir string) appBundleModel {
+	bundle := appBundleModel{}
+
+	if err := filepath.Walk(dir, func(pth string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if info.IsDir() {
+			if name := strings.ToLower(info.Name()); name == "bin" || name == "obj" || name == "packages" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !strings.EqualFold(filepath.Ext(pth), ".cs") {
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(pth)
+		if err != nil {
+			return nil
+		}
+
+		for _, match := range installedAppPattern.FindAllStringSubmatch(string(content), -1) {
+			bundle.bundleIDs = append(bundle.bundleIDs, match[1])
+		}
+		for _, match := range appBundlePattern.FindAllStringSubmatch(string(content), -1) {
+			appPth := strings.TrimRight(strings.Replace(match[1], `\`, "/", -1), "/")
+			if name := strings.TrimSuffix(filepath.Base(appPth), ".app"); name != "" {
+				bundle.bundleNames = append(bundle.bundleNames, name)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return appBundleModel{}
+	}
+
+	return bundle
+}
@@ -0,0 +1,134 @@
+package pairing
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-tools/go-xamarin/analyzers/project"
+	"github.com/bitrise-tools/go-xamarin/analyzers/solution"
+)
+
+var inferSolutionPth = filepath.Join("testdata", "Infer", "Infer.sln")
+
+func TestInfer(t *testing.T) {
+	sln, err := solution.New(inferSolutionPth, true)
+	if err != nil {
+		t.Fatalf("failed to analyze solution: %s", err)
+	}
+
+	tests := []struct {
+		name           string
+		testProject    string
+		wantAppProject string
+		wantConfidence Confidence
+		wantSkipped    string
+	}{
+		{
+			name:           "bundle id",
+			testProject:    "BundleTests",
+			wantAppProject: "Shop.Admin.iOS",
+			wantConfidence: ConfidenceHigh,
+		},
+		{
+			name:           "app bundle",
+			testProject:    "AppBundleTests",
+			wantAppProject: "Kiosk.iOS",
+			wantConfidence: ConfidenceHigh,
+		},
+		{
+			name:           "longest name prefix",
+			testProject:    "Shop.Admin.UITests",
+			wantAppProject: "Shop.Admin.iOS",
+			wantConfidence: ConfidenceMedium,
+		},
+		{
+			name:        "ambiguous name prefix",
+			testProject: "Shop.UITests",
+			wantSkipped: "Shop.UITests: ambiguous, the name prefix (Shop) is shared by (Shop.Admin.iOS, Shop.iOS)",
+		},
+		{
+			name:        "solution folder only",
+			testProject: "Acceptance",
+			wantSkipped: "Acceptance: only the solution folder of (Kiosk.iOS) is nearby",
+		},
+		{
+			name:        "no match",
+			testProject: "Smoke",
+			wantSkipped: "Smoke: no matching iOS app project",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inferred, skipped := Infer(sln, []string{test.testProject})
+
+			if test.wantSkipped != "" {
+				if len(inferred) != 0 {
+					t.Errorf("inferred: got %+v, want none", inferred)
+				}
+				if len(skipped) != 1 || !strings.HasPrefix(skipped[0], test.wantSkipped) {
+					t.Errorf("skipped: got %v, want %q...", skipped, test.wantSkipped)
+				}
+				return
+			}
+
+			if len(skipped) != 0 {
+				t.Errorf("skipped: got %v, want none", skipped)
+			}
+			want := Model{TestProject: test.testProject, AppProject: test.wantAppProject}
+			if len(inferred) != 1 || !reflect.DeepEqual(inferred[0].Model, want) {
+				t.Fatalf("inferred: got %+v, want %+v", inferred, want)
+			}
+			if inferred[0].Confidence != test.wantConfidence {
+				t.Errorf("confidence: got %s, want %s (score: %d, reasons: %v)", inferred[0].Confidence, test.wantConfidence, inferred[0].Score, inferred[0].Reasons)
+			}
+		})
+	}
+}
+
+func TestInferMissingTestProject(t *testing.T) {
+	inferred, skipped := Infer(solution.Model{ProjectMap: map[string]project.Model{}}, []string{"Missing.UITests"})
+	if len(inferred) != 0 {
+		t.Errorf("inferred: got %+v, want none", inferred)
+	}
+	if want := []string{"Missing.UITests: not found in solution"}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped: got %v, want %v", skipped, want)
+	}
+}
+
+func TestSolutionFoldersDistance(t *testing.T) {
+	folders := readSolutionFolders(inferSolutionPth)
+
+	const (
+		shopIOS        = "7A1C0E52-3B8D-4F6A-9C2E-5D0F1B2C3D01"
+		shopUITests    = "7A1C0E52-3B8D-4F6A-9C2E-5D0F1B2C3D02"
+		shopAdminIOS   = "7A1C0E52-3B8D-4F6A-9C2E-5D0F1B2C3D03"
+		kioskIOS       = "7A1C0E52-3B8D-4F6A-9C2E-5D0F1B2C3D06"
+		appBundleTests = "7A1C0E52-3B8D-4F6A-9C2E-5D0F1B2C3D08"
+		smoke          = "7A1C0E52-3B8D-4F6A-9C2E-5D0F1B2C3D09"
+	)
+
+	tests := []struct {
+		name       string
+		a, b       string
+		wantSteps  int
+		wantFolder string
+		wantOK     bool
+	}{
+		{"same folder", shopUITests, shopIOS, 0, "Shop", true},
+		{"nested folder", appBundleTests, kioskIOS, 1, "Kiosk", true},
+		{"sibling folders under the root", shopUITests, shopAdminIOS, 0, "", false},
+		{"project in the root", smoke, shopIOS, 0, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			steps, folder, ok := folders.distance(test.a, test.b)
+			if steps != test.wantSteps || folder != test.wantFolder || ok != test.wantOK {
+				t.Errorf("got (%d, %q, %t), want (%d, %q, %t)", steps, folder, ok, test.wantSteps, test.wantFolder, test.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package pairing
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-tools/go-xamarin/analyzers/project"
+	"github.com/bitrise-tools/go-xamarin/analyzers/solution"
+	"github.com/bitrise-tools/go-xamarin/builder"
+)
+
+var solutionProjectPattern = regexp.MustCompile(`^\s*Project\("\{[^}]*\}"\)\s*=\s*"([^"]*)"`)
+
+// SolutionOrder returns the position of every project in the solution file.
+func SolutionOrder(solutionPth string) (map[string]int, error) {
+	file, err := os.Open(solutionPth)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Warnf("Failed to close solution file, error: %s", err)
+		}
+	}()
+
+	order := map[string]int{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if matches := solutionProjectPattern.FindStringSubmatch(scanner.Text()); len(matches) == 2 {
+			if _, ok := order[matches[1]]; !ok {
+				order[matches[1]] = len(order)
+			}
+		}
+	}
+
+	return order, scanner.Err()
+}
+
+// less compares two project names by their solution file order, then by name,
+// the second return value is false if the names are equal.
+func less(order map[string]int, a, b string) (bool, bool) {
+	aOrder, aOK := order[a]
+	bOrder, bOK := order[b]
+	switch {
+	case aOK && bOK && aOrder != bOrder:
+		return aOrder < bOrder, true
+	case aOK != bOK:
+		return aOK, true
+	case a != b:
+		return a < b, true
+	}
+	return false, false
+}
+
+// Sort orders the pairs by the solution file order of the test projects, then of the app projects.
+// Projects not found in the solution file come last, ordered by name.
+func Sort(pairs []Model, order map[string]int) {
+	sort.SliceStable(pairs, func(i, j int) bool {
+		if isLess, ok := less(order, pairs[i].TestProject, pairs[j].TestProject); ok {
+			return isLess
+		}
+		isLess, _ := less(order, pairs[i].AppProject, pairs[j].AppProject)
+		return isLess
+	})
+}
+
+// TestProjectNames returns the names of the test projects in solution file order.
+// Projects not found in the solution file come last, ordered by name.
+func TestProjectNames(testProjectOutputMap builder.TestProjectOutputMap, order map[string]int) []string {
+	names := []string{}
+	for name := range testProjectOutputMap {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		isLess, _ := less(order, names[i], names[j])
+		return isLess
+	})
+	return names
+}
+
+// Projects returns the projects of the solution in solution file order,
+// the analyzed solution keeps them in a map.
+// Projects not found in the solution file come last, ordered by name.
+func Projects(sln solution.Model, order map[string]int) []project.Model {
+	projects := []project.Model{}
+	for _, proj := range sln.ProjectMap {
+		projects = append(projects, proj)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if isLess, ok := less(order, projects[i].Name, projects[j].Name); ok {
+			return isLess
+		}
+		return projects[i].ID < projects[j].ID
+	})
+	return projects
+}
@@ -0,0 +1,160 @@
+package pairing
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-tools/go-xamarin/analyzers/solution"
+	"github.com/bitrise-tools/go-xamarin/builder"
+	"github.com/bitrise-tools/go-xamarin/constants"
+	"github.com/bitrise-tools/go-xamarin/tools/buildtools"
+)
+
+const fixtureSolutionPth = "testdata/Sample.sln"
+
+func TestSolutionOrder(t *testing.T) {
+	order, err := SolutionOrder(fixtureSolutionPth)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// solution folders (Tests) are listed as projects too, only the relative order matters
+	want := map[string]int{
+		"Sample.iOS":     0,
+		"Sample.UITests": 1,
+		"Tests":          2,
+		"Admin.iOS":      3,
+		"Admin.UITests":  4,
+		"Core":           5,
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("got %v, want %v", order, want)
+	}
+
+	if _, err := SolutionOrder("testdata/Missing.sln"); err == nil {
+		t.Errorf("expected error for missing solution")
+	}
+}
+
+func TestSort(t *testing.T) {
+	order := map[string]int{"Sample.iOS": 0, "Sample.UITests": 1, "Admin.iOS": 2, "Admin.UITests": 3}
+
+	pairs := []Model{
+		{TestProject: "Unknown.UITests", AppProject: "Sample.iOS"},
+		{TestProject: "Admin.UITests", AppProject: "Sample.iOS"},
+		{TestProject: "Admin.UITests", AppProject: "Admin.iOS"},
+		{TestProject: "Extra.UITests", AppProject: "Admin.iOS"},
+		{TestProject: "Sample.UITests", AppProject: "Unknown.iOS"},
+		{TestProject: "Sample.UITests", AppProject: "Admin.iOS"},
+		{TestProject: "Sample.UITests", AppProject: "Sample.iOS"},
+	}
+	Sort(pairs, order)
+
+	want := []Model{
+		{TestProject: "Sample.UITests", AppProject: "Sample.iOS"},
+		{TestProject: "Sample.UITests", AppProject: "Admin.iOS"},
+		{TestProject: "Sample.UITests", AppProject: "Unknown.iOS"},
+		{TestProject: "Admin.UITests", AppProject: "Sample.iOS"},
+		{TestProject: "Admin.UITests", AppProject: "Admin.iOS"},
+		// projects not found in the solution come last, ordered by name
+		{TestProject: "Extra.UITests", AppProject: "Admin.iOS"},
+		{TestProject: "Unknown.UITests", AppProject: "Sample.iOS"},
+	}
+	if !reflect.DeepEqual(pairs, want) {
+		t.Errorf("got:\n%v\nwant:\n%v", pairs, want)
+	}
+}
+
+func TestTestProjectNames(t *testing.T) {
+	order := map[string]int{"Sample.UITests": 1, "Admin.UITests": 4}
+	testProjectOutputMap := builder.TestProjectOutputMap{
+		"Admin.UITests":  builder.TestProjectOutputModel{},
+		"Sample.UITests": builder.TestProjectOutputModel{},
+		"Other.UITests":  builder.TestProjectOutputModel{},
+		"Extra.UITests":  builder.TestProjectOutputModel{},
+	}
+
+	want := []string{"Sample.UITests", "Admin.UITests", "Extra.UITests", "Other.UITests"}
+	if names := TestProjectNames(testProjectOutputMap, order); !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+}
+
+// TestPlanIsStable checks that the same solution results in the same pairs on every run,
+// regardless of the iteration order of the maps.
+func TestPlanIsStable(t *testing.T) {
+	order, err := SolutionOrder(fixtureSolutionPth)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	testProjects := []struct {
+		name                 string
+		referredProjectNames []string
+	}{
+		{"Sample.UITests", []string{"Sample.iOS", "Admin.iOS"}},
+		{"Admin.UITests", []string{"Admin.iOS"}},
+		{"Extra.UITests", []string{"Sample.iOS"}},
+	}
+
+	want := []Model{
+		{TestProject: "Sample.UITests", AppProject: "Sample.iOS"},
+		{TestProject: "Sample.UITests", AppProject: "Admin.iOS"},
+		{TestProject: "Admin.UITests", AppProject: "Admin.iOS"},
+		{TestProject: "Extra.UITests", AppProject: "Sample.iOS"},
+	}
+	wantProjects := []string{"Sample.iOS", "Sample.UITests", "Admin.iOS", "Admin.UITests", "Core"}
+
+	random := rand.New(rand.NewSource(1))
+	for run := 0; run < 20; run++ {
+		testProjectOutputMap := builder.TestProjectOutputMap{}
+		for _, i := range random.Perm(len(testProjects)) {
+			testProjectOutputMap[testProjects[i].name] = builder.TestProjectOutputModel{ReferredProjectNames: testProjects[i].referredProjectNames}
+		}
+
+		pairs := FromReferences(testProjectOutputMap, order)
+		Sort(pairs, order)
+		if !reflect.DeepEqual(pairs, want) {
+			t.Fatalf("run %d:\ngot:  %v\nwant: %v", run, pairs, want)
+		}
+
+		sln, err := solution.New(fixtureSolutionPth, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		projects := []string{}
+		for _, proj := range Projects(sln, order) {
+			projects = append(projects, proj.Name)
+		}
+		if !reflect.DeepEqual(projects, wantProjects) {
+			t.Fatalf("run %d: solution projects:\ngot:  %v\nwant: %v", run, projects, wantProjects)
+		}
+	}
+}
+
+// TestBuilderProjectListsAreStable checks that the builder lists the projects in solution file order on every run.
+func TestBuilderProjectListsAreStable(t *testing.T) {
+	wantProjects := []string{"Sample.iOS", "Admin.iOS"}
+	wantTestProjects := []string{"Sample.UITests", "Admin.UITests"}
+	wantReferredProjects := []string{"Sample.iOS", "Admin.iOS"}
+
+	for run := 0; run < 20; run++ {
+		b, err := builder.New(fixtureSolutionPth, []constants.SDK{constants.SDKIOS}, buildtools.Msbuild)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if projects := b.BuildableProjectNames("Release", "iPhone"); !reflect.DeepEqual(projects, wantProjects) {
+			t.Fatalf("run %d: buildable projects:\ngot:  %v\nwant: %v", run, projects, wantProjects)
+		}
+
+		testProjects, referredProjects := b.BuildableXamarinUITestProjectNames("Release", "iPhone")
+		if !reflect.DeepEqual(testProjects, wantTestProjects) {
+			t.Fatalf("run %d: test projects:\ngot:  %v\nwant: %v", run, testProjects, wantTestProjects)
+		}
+		if !reflect.DeepEqual(referredProjects, wantReferredProjects) {
+			t.Fatalf("run %d: referred projects:\ngot:  %v\nwant: %v", run, referredProjects, wantReferredProjects)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package pairing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/devices"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/series"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/testfilter"
+	"github.com/bitrise-tools/go-xamarin/analyzers/solution"
+	"github.com/bitrise-tools/go-xamarin/builder"
+	"github.com/bitrise-tools/go-xamarin/constants"
+	"github.com/bitrise-tools/go-xamarin/utility"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Model describes a UITest project to run against an app project.
+type Model struct {
+	TestProject string   `json:"test_project" yaml:"test_project"`
+	AppProject  string   `json:"app_project" yaml:"app_project"`
+	Devices     string   `json:"devices,omitempty" yaml:"devices,omitempty"`
+	Series      string   `json:"series,omitempty" yaml:"series,omitempty"`
+	Categories  []string `json:"categories,omitempty" yaml:"categories,omitempty"`
+	Fixtures    []string `json:"fixtures,omitempty" yaml:"fixtures,omitempty"`
+}
+
+// Filters returns the category and fixture filters of the pair.
+func (pair Model) Filters() []testfilter.Model {
+	filters := []testfilter.Model{}
+	for _, category := range pair.Categories {
+		filters = append(filters, testfilter.Model{Kind: testfilter.KindCategory, Value: category})
+	}
+	for _, fixture := range pair.Fixtures {
+		filters = append(filters, testfilter.Model{Kind: testfilter.KindFixture, Value: fixture})
+	}
+	return filters
+}
+
+// ParseMapping parses a JSON or YAML list of pairs, given inline or as a path to a file.
+// Files with .yml or .yaml extension are read as YAML, every other file as JSON.
+func ParseMapping(mapping string) ([]Model, error) {
+	content := []byte(mapping)
+	isYAML := false
+
+	trimmed := strings.TrimSpace(mapping)
+	switch {
+	case strings.HasPrefix(trimmed, "["):
+	case strings.HasPrefix(trimmed, "-"):
+		isYAML = true
+	default:
+		fileContent, err := ioutil.ReadFile(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("mapping is neither a JSON or YAML list nor a readable file, error: %s", err)
+		}
+		content = fileContent
+
+		ext := strings.ToLower(filepath.Ext(trimmed))
+		isYAML = ext == ".yml" || ext == ".yaml"
+	}
+
+	var pairs []Model
+	if isYAML {
+		if err := yaml.Unmarshal(content, &pairs); err != nil {
+			return nil, fmt.Errorf("failed to parse mapping as YAML, error: %s", err)
+		}
+	} else if err := json.Unmarshal(content, &pairs); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping as JSON, error: %s", err)
+	}
+
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("mapping does not contain any pair")
+	}
+
+	seen := map[string]bool{}
+	for i, pair := range pairs {
+		if pair.TestProject == "" {
+			return nil, fmt.Errorf("pair #%d: test_project is not set", i+1)
+		}
+		if pair.AppProject == "" {
+			return nil, fmt.Errorf("pair #%d: app_project is not set", i+1)
+		}
+
+		if pair.Devices != "" {
+			if _, err := devices.ParseSelections(pair.Devices); err != nil {
+				return nil, fmt.Errorf("pair #%d: %s", i+1, err)
+			}
+		}
+
+		if pair.Series != "" {
+			if err := series.Validate(pair.Series); err != nil {
+				return nil, fmt.Errorf("pair #%d: %s", i+1, err)
+			}
+		}
+
+		key := pair.TestProject + "|" + pair.AppProject
+		if seen[key] {
+			return nil, fmt.Errorf("pair #%d: (%s) - (%s) is listed multiple times", i+1, pair.TestProject, pair.AppProject)
+		}
+		seen[key] = true
+	}
+
+	return pairs, nil
+}
+
+// FromReferences pairs the test projects with the projects they refer to,
+// in the solution file order of the test projects.
+func FromReferences(testProjectOutputMap builder.TestProjectOutputMap, order map[string]int) []Model {
+	pairs := []Model{}
+	for _, testProjectName := range TestProjectNames(testProjectOutputMap, order) {
+		for _, projectName := range testProjectOutputMap[testProjectName].ReferredProjectNames {
+			pairs = append(pairs, Model{TestProject: testProjectName, AppProject: projectName})
+		}
+	}
+	return pairs
+}
+
+// TestProjectOutput locates the assembly of a built Xamarin.UITest project,
+// for test projects not collected by the builder, because they do not refer to any app project.
+func TestProjectOutput(sln solution.Model, projectName, configuration, platform string) (builder.TestProjectOutputModel, error) {
+	for _, proj := range sln.ProjectMap {
+		if proj.Name != projectName {
+			continue
+		}
+
+		if proj.TestFramework != constants.TestFrameworkXamarinUITest {
+			return builder.TestProjectOutputModel{}, fmt.Errorf("project (%s) is not a Xamarin.UITest project", projectName)
+		}
+
+		projectConfigKey, ok := proj.ConfigMap[utility.ToConfig(configuration, platform)]
+		if !ok {
+			return builder.TestProjectOutputModel{}, fmt.Errorf("project (%s) does not have config for solution config (%s|%s)", projectName, configuration, platform)
+		}
+
+		projectConfig, ok := proj.Configs[projectConfigKey]
+		if !ok {
+			return builder.TestProjectOutputModel{}, fmt.Errorf("project (%s) does not have config (%s)", projectName, projectConfigKey)
+		}
+
+		dllPth := filepath.Join(projectConfig.OutputDir, proj.AssemblyName+".dll")
+		if _, err := os.Stat(dllPth); err != nil {
+			return builder.TestProjectOutputModel{}, fmt.Errorf("project (%s) assembly not found, error: %s", projectName, err)
+		}
+
+		return builder.TestProjectOutputModel{
+			TestFramwork: proj.TestFramework,
+			Output: builder.OutputModel{
+				Pth:        dllPth,
+				OutputType: constants.OutputTypeDLL,
+			},
+		}, nil
+	}
+
+	return builder.TestProjectOutputModel{}, fmt.Errorf("project (%s) not found in solution", projectName)
+}
@@ -0,0 +1,74 @@
+package pairing
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMapping(t *testing.T) {
+	want := []Model{
+		{TestProject: "Sample.UITests", AppProject: "Sample.iOS", Devices: "phones=a1b2c3d4", Categories: []string{"Smoke"}},
+		{TestProject: "Admin.UITests", AppProject: "Admin.iOS", Series: "admin", Fixtures: []string{"Admin.UITests.LoginTests"}},
+	}
+
+	tests := []struct {
+		name    string
+		mapping string
+	}{
+		{"inline json", `[
+  {"test_project": "Sample.UITests", "app_project": "Sample.iOS", "devices": "phones=a1b2c3d4", "categories": ["Smoke"]},
+  {"test_project": "Admin.UITests", "app_project": "Admin.iOS", "series": "admin", "fixtures": ["Admin.UITests.LoginTests"]}
+]`},
+		{"inline yaml", `
+- test_project: Sample.UITests
+  app_project: Sample.iOS
+  devices: phones=a1b2c3d4
+  categories: [Smoke]
+- {test_project: Admin.UITests, app_project: Admin.iOS, series: admin, fixtures: [Admin.UITests.LoginTests]}
+`},
+		{"json file", filepath.Join("testdata", "mapping.json")},
+		{"yaml file", filepath.Join("testdata", "mapping.yml")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pairs, err := ParseMapping(test.mapping)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(pairs, want) {
+				t.Errorf("got %+v, want %+v", pairs, want)
+			}
+		})
+	}
+}
+
+func TestParseMappingError(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping string
+		wantErr string
+	}{
+		{"missing file", filepath.Join("testdata", "missing.yml"), "mapping is neither a JSON or YAML list nor a readable file"},
+		{"invalid json", `[{"test_project": "Sample.UITests"`, "failed to parse mapping as JSON"},
+		{"invalid yaml", "- test_project: [Sample.UITests", "failed to parse mapping as YAML"},
+		{"other file read as json", filepath.Join("testdata", "Sample.sln"), "failed to parse mapping as JSON"},
+		{"empty list", "[]", "mapping does not contain any pair"},
+		{"missing test project", "- app_project: Sample.iOS", "pair #1: test_project is not set"},
+		{"missing app project", `[{"test_project": "Sample.UITests"}]`, "pair #1: app_project is not set"},
+		{"invalid devices", "- {test_project: Sample.UITests, app_project: Sample.iOS, devices: 'my phones=a1b2c3d4'}", "pair #1: invalid device selection label (my phones)"},
+		{"invalid series", "- {test_project: Sample.UITests, app_project: Sample.iOS, series: '{{.Branch'}", "pair #1:"},
+		{"duplicate pair", "- {test_project: Sample.UITests, app_project: Sample.iOS}\n- {test_project: Sample.UITests, app_project: Sample.iOS}", "pair #2: (Sample.UITests) - (Sample.iOS) is listed multiple times"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseMapping(test.mapping)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("error: got %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+using NUnit.Framework;
+using Xamarin.UITest;
+
+namespace AppBundleTests
+{
+	[TestFixture]
+	public class Tests
+	{
+		IApp app;
+
+		[SetUp]
+		public void BeforeEachTest()
+		{
+			app = ConfigureApp.iOS.AppBundle(@"..\Kiosk.iOS\bin\iPhone\Release\Kiosk.iOS.app").StartApp();
+		}
+	}
+}
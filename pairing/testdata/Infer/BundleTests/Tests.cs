@@ -0,0 +1,17 @@
+using NUnit.Framework;
+using Xamarin.UITest;
+
+namespace BundleTests
+{
+	[TestFixture]
+	public class Tests
+	{
+		IApp app;
+
+		[SetUp]
+		public void BeforeEachTest()
+		{
+			app = ConfigureApp.iOS.InstalledApp("io.bitrise.shop.admin").StartApp();
+		}
+	}
+}
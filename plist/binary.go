@@ -0,0 +1,292 @@
+package plist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	binaryHeader      = "bplist00"
+	binaryTrailerSize = 32
+	maxBinaryDepth    = 128
+)
+
+// Reference date of binary plist dates: 2001-01-01 00:00:00 UTC
+var binaryDateEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+type binaryParser struct {
+	content       []byte
+	offsets       []uint64
+	objectRefSize int
+	// refs of the containers being parsed, to detect reference cycles
+	parsing map[uint64]bool
+}
+
+func isBinary(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(binaryHeader))
+}
+
+func parseBinary(content []byte) (interface{}, error) {
+	if len(content) < len(binaryHeader)+binaryTrailerSize {
+		return nil, fmt.Errorf("binary plist too short")
+	}
+
+	trailer := content[len(content)-binaryTrailerSize:]
+	offsetIntSize := int(trailer[6])
+	objectRefSize := int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:16])
+	topObject := binary.BigEndian.Uint64(trailer[16:24])
+	offsetTableOffset := binary.BigEndian.Uint64(trailer[24:32])
+
+	if offsetIntSize < 1 || offsetIntSize > 8 || objectRefSize < 1 || objectRefSize > 8 {
+		return nil, fmt.Errorf("invalid binary plist trailer")
+	}
+	if numObjects == 0 || topObject >= numObjects {
+		return nil, fmt.Errorf("invalid binary plist object count")
+	}
+	tableEnd := offsetTableOffset + numObjects*uint64(offsetIntSize)
+	if numObjects > uint64(len(content)) || tableEnd > uint64(len(content)) || tableEnd < offsetTableOffset {
+		return nil, fmt.Errorf("invalid binary plist offset table")
+	}
+
+	parser := &binaryParser{content: content, objectRefSize: objectRefSize, parsing: map[uint64]bool{}}
+	for i := uint64(0); i < numObjects; i++ {
+		start := offsetTableOffset + i*uint64(offsetIntSize)
+		parser.offsets = append(parser.offsets, readUint(content[start:start+uint64(offsetIntSize)]))
+	}
+
+	return parser.object(topObject, 0)
+}
+
+func readUint(data []byte) uint64 {
+	value := uint64(0)
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+func (parser *binaryParser) bytes(offset, length uint64) ([]byte, error) {
+	end := offset + length
+	if end < offset || end > uint64(len(parser.content)) {
+		return nil, fmt.Errorf("binary plist object out of bounds")
+	}
+	return parser.content[offset:end], nil
+}
+
+func (parser *binaryParser) object(ref uint64, depth int) (interface{}, error) {
+	if depth > maxBinaryDepth {
+		return nil, fmt.Errorf("binary plist nested too deep")
+	}
+	if ref >= uint64(len(parser.offsets)) {
+		return nil, fmt.Errorf("invalid binary plist object reference: %d", ref)
+	}
+
+	offset := parser.offsets[ref]
+	marker, err := parser.bytes(offset, 1)
+	if err != nil {
+		return nil, err
+	}
+	kind := marker[0] >> 4
+	info := marker[0] & 0x0f
+	offset++
+
+	switch kind {
+	case 0x0:
+		switch info {
+		case 0x8:
+			return false, nil
+		case 0x9:
+			return true, nil
+		}
+		return nil, nil
+	case 0x1:
+		data, err := parser.bytes(offset, 1<<info)
+		if err != nil {
+			return nil, err
+		}
+		switch len(data) {
+		case 8:
+			return int64(binary.BigEndian.Uint64(data)), nil
+		case 16:
+			// 128 bit integers are only used for unsigned values not fitting into int64
+			high, low := binary.BigEndian.Uint64(data[:8]), binary.BigEndian.Uint64(data[8:])
+			if high == 0 && low > math.MaxInt64 {
+				return low, nil
+			}
+			if (high == 0 && low <= math.MaxInt64) || (high == math.MaxUint64 && low > math.MaxInt64) {
+				return int64(low), nil
+			}
+			return nil, fmt.Errorf("binary plist integer out of range")
+		}
+		if len(data) > 8 {
+			return nil, fmt.Errorf("unsupported binary plist integer size: %d", len(data))
+		}
+		return int64(readUint(data)), nil
+	case 0x2:
+		data, err := parser.bytes(offset, 1<<info)
+		if err != nil {
+			return nil, err
+		}
+		switch len(data) {
+		case 4:
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+		}
+		return nil, fmt.Errorf("unsupported binary plist real size: %d", len(data))
+	case 0x3:
+		data, err := parser.bytes(offset, 8)
+		if err != nil {
+			return nil, err
+		}
+		seconds := math.Float64frombits(binary.BigEndian.Uint64(data))
+		return binaryDateEpoch.Add(time.Duration(seconds * float64(time.Second))), nil
+	case 0x4:
+		count, offset, err := parser.count(info, offset)
+		if err != nil {
+			return nil, err
+		}
+		data, err := parser.bytes(offset, count)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, data...), nil
+	case 0x5:
+		count, offset, err := parser.count(info, offset)
+		if err != nil {
+			return nil, err
+		}
+		data, err := parser.bytes(offset, count)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	case 0x6:
+		count, offset, err := parser.count(info, offset)
+		if err != nil {
+			return nil, err
+		}
+		data, err := parser.bytes(offset, count*2)
+		if err != nil {
+			return nil, err
+		}
+		chars := make([]uint16, count)
+		for i := range chars {
+			chars[i] = binary.BigEndian.Uint16(data[i*2:])
+		}
+		return string(utf16.Decode(chars)), nil
+	case 0x8:
+		data, err := parser.bytes(offset, uint64(info)+1)
+		if err != nil {
+			return nil, err
+		}
+		return int64(readUint(data)), nil
+	case 0xa, 0xc:
+		if parser.parsing[ref] {
+			return nil, fmt.Errorf("binary plist reference cycle")
+		}
+		parser.parsing[ref] = true
+		defer delete(parser.parsing, ref)
+
+		count, offset, err := parser.count(info, offset)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := parser.refs(offset, count)
+		if err != nil {
+			return nil, err
+		}
+
+		array := []interface{}{}
+		for _, ref := range refs {
+			value, err := parser.object(ref, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		return array, nil
+	case 0xd:
+		if parser.parsing[ref] {
+			return nil, fmt.Errorf("binary plist reference cycle")
+		}
+		parser.parsing[ref] = true
+		defer delete(parser.parsing, ref)
+
+		count, offset, err := parser.count(info, offset)
+		if err != nil {
+			return nil, err
+		}
+		refs, err := parser.refs(offset, count*2)
+		if err != nil {
+			return nil, err
+		}
+
+		dict := map[string]interface{}{}
+		for i := uint64(0); i < count; i++ {
+			key, err := parser.object(refs[i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			keyStr, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("binary plist dict key is not a string")
+			}
+
+			value, err := parser.object(refs[count+i], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			dict[keyStr] = value
+		}
+		return dict, nil
+	}
+
+	return nil, fmt.Errorf("unknown binary plist object type: 0x%x", marker[0])
+}
+
+// count returns the element count of the object and the offset of its content.
+func (parser *binaryParser) count(info byte, offset uint64) (uint64, uint64, error) {
+	if info != 0xf {
+		return uint64(info), offset, nil
+	}
+
+	marker, err := parser.bytes(offset, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if marker[0]>>4 != 0x1 {
+		return 0, 0, fmt.Errorf("invalid binary plist object count")
+	}
+
+	size := uint64(1) << (marker[0] & 0x0f)
+	data, err := parser.bytes(offset+1, size)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	count := readUint(data)
+	if count > uint64(len(parser.content)) {
+		return 0, 0, fmt.Errorf("invalid binary plist object count")
+	}
+	return count, offset + 1 + size, nil
+}
+
+func (parser *binaryParser) refs(offset, count uint64) ([]uint64, error) {
+	data, err := parser.bytes(offset, count*uint64(parser.objectRefSize))
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []uint64{}
+	for i := uint64(0); i < count; i++ {
+		start := i * uint64(parser.objectRefSize)
+		refs = append(refs, readUint(data[start:start+uint64(parser.objectRefSize)]))
+	}
+	return refs, nil
+}
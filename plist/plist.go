@@ -0,0 +1,242 @@
+package plist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Data ...
+type Data map[string]interface{}
+
+// NewDataFromContent parses a property list with a dictionary root element.
+func NewDataFromContent(content []byte) (Data, error) {
+	value, err := Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("root element is not a dictionary")
+	}
+
+	return Data(data), nil
+}
+
+// Parse parses an XML or binary property list into dictionaries (map[string]interface{}), arrays ([]interface{}),
+// strings, int64, float64, bool, time.Time and []byte values.
+func Parse(content []byte) (interface{}, error) {
+	if isBinary(content) {
+		return parseBinary(content)
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("<")) {
+		return parseXML(content)
+	}
+	return nil, fmt.Errorf("unsupported property list format")
+}
+
+// GetString ...
+func (data Data) GetString(key string) (string, bool) {
+	value, ok := data[key].(string)
+	return value, ok
+}
+
+// GetInt ...
+func (data Data) GetInt(key string) (int64, bool) {
+	value, ok := data[key].(int64)
+	return value, ok
+}
+
+// GetBool ...
+func (data Data) GetBool(key string) (bool, bool) {
+	value, ok := data[key].(bool)
+	return value, ok
+}
+
+// GetTime ...
+func (data Data) GetTime(key string) (time.Time, bool) {
+	value, ok := data[key].(time.Time)
+	return value, ok
+}
+
+// GetData ...
+func (data Data) GetData(key string) (Data, bool) {
+	value, ok := data[key].(map[string]interface{})
+	return Data(value), ok
+}
+
+// GetStringArray ...
+func (data Data) GetStringArray(key string) ([]string, bool) {
+	values, ok := data[key].([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	strs := []string{}
+	for _, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		strs = append(strs, str)
+	}
+	return strs, true
+}
+
+// GetIntArray ...
+func (data Data) GetIntArray(key string) ([]int64, bool) {
+	values, ok := data[key].([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	ints := []int64{}
+	for _, value := range values {
+		i, ok := value.(int64)
+		if !ok {
+			return nil, false
+		}
+		ints = append(ints, i)
+	}
+	return ints, true
+}
+
+func parseXML(content []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no plist element found")
+		} else if err != nil {
+			return nil, err
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "plist" {
+			for {
+				token, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				switch element := token.(type) {
+				case xml.StartElement:
+					return parseXMLValue(decoder, element)
+				case xml.EndElement:
+					return nil, fmt.Errorf("empty plist element")
+				}
+			}
+		}
+	}
+}
+
+func parseXMLValue(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]interface{}{}
+		key := ""
+		hasKey := false
+
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			switch element := token.(type) {
+			case xml.StartElement:
+				if element.Name.Local == "key" {
+					if key, err = xmlText(decoder); err != nil {
+						return nil, err
+					}
+					hasKey = true
+					continue
+				}
+
+				if !hasKey {
+					return nil, fmt.Errorf("dict value (%s) without key", element.Name.Local)
+				}
+
+				value, err := parseXMLValue(decoder, element)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+				hasKey = false
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		array := []interface{}{}
+
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			switch element := token.(type) {
+			case xml.StartElement:
+				value, err := parseXMLValue(decoder, element)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		if err := decoder.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	text, err := xmlText(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	switch start.Name.Local {
+	case "string":
+		return text, nil
+	case "integer":
+		return strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	case "real":
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	case "date":
+		return time.Parse(time.RFC3339, strings.TrimSpace(text))
+	case "data":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	default:
+		return nil, fmt.Errorf("unknown plist element: %s", start.Name.Local)
+	}
+}
+
+func xmlText(decoder *xml.Decoder) (string, error) {
+	text := ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+
+		switch element := token.(type) {
+		case xml.CharData:
+			text += string(element)
+		case xml.EndElement:
+			return text, nil
+		case xml.StartElement:
+			return "", fmt.Errorf("unexpected element (%s) in text value", element.Name.Local)
+		}
+	}
+}
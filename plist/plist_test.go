@@ -0,0 +1,186 @@
+package plist
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %s", err)
+	}
+	return content
+}
+
+func TestNewDataFromContent(t *testing.T) {
+	for _, fixture := range []string{"Info.xml.plist", "Info.binary.plist"} {
+		t.Run(fixture, func(t *testing.T) {
+			data, err := NewDataFromContent(readFixture(t, fixture))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			strs := map[string]string{
+				"CFBundleIdentifier":         "com.example.testcloud",
+				"CFBundleDisplayName":        "Test Cloud Sample",
+				"CFBundleShortVersionString": "1.2.3",
+				"CFBundleVersion":            "42",
+				"MinimumOSVersion":           "10.0",
+				// non ASCII strings are stored as UTF-16 in binary plists
+				"NSHumanReadableCopyright": "© 2017 Példa Kft.",
+			}
+			for key, want := range strs {
+				if got, ok := data.GetString(key); !ok || got != want {
+					t.Errorf("%s: got (%q, %v), want %q", key, got, ok, want)
+				}
+			}
+
+			if got, ok := data.GetIntArray("UIDeviceFamily"); !ok || !reflect.DeepEqual(got, []int64{1, 2}) {
+				t.Errorf("UIDeviceFamily: got (%v, %v)", got, ok)
+			}
+			if got, ok := data.GetStringArray("CFBundleSupportedPlatforms"); !ok || !reflect.DeepEqual(got, []string{"iPhoneOS"}) {
+				t.Errorf("CFBundleSupportedPlatforms: got (%v, %v)", got, ok)
+			}
+			if got, ok := data.GetBool("LSRequiresIPhoneOS"); !ok || !got {
+				t.Errorf("LSRequiresIPhoneOS: got (%v, %v)", got, ok)
+			}
+			if got, ok := data.GetBool("UIStatusBarHidden"); !ok || got {
+				t.Errorf("UIStatusBarHidden: got (%v, %v)", got, ok)
+			}
+			if got, ok := data.GetInt("SampleNegative"); !ok || got != -7 {
+				t.Errorf("SampleNegative: got (%v, %v)", got, ok)
+			}
+			if got, ok := data.GetInt("SampleLarge"); !ok || got != math.MaxInt64 {
+				t.Errorf("SampleLarge: got (%v, %v)", got, ok)
+			}
+			if got, ok := data["SampleReal"].(float64); !ok || got != 1.5 {
+				t.Errorf("SampleReal: got (%v, %v)", got, ok)
+			}
+			if got, ok := data.GetTime("SampleDate"); !ok || !got.Equal(time.Date(2017, 5, 6, 7, 8, 9, 0, time.UTC)) {
+				t.Errorf("SampleDate: got (%v, %v)", got, ok)
+			}
+			if got, ok := data["SampleData"].([]byte); !ok || string(got) != "\x00\x01\x02testcloud" {
+				t.Errorf("SampleData: got (%q, %v)", got, ok)
+			}
+
+			nested, ok := data.GetData("SampleNested")
+			if !ok {
+				t.Fatalf("SampleNested: not a dictionary")
+			}
+			if got, ok := nested.GetInt("Count"); !ok || got != 300 {
+				t.Errorf("SampleNested.Count: got (%v, %v)", got, ok)
+			}
+		})
+	}
+}
+
+func TestParseBinary128BitInteger(t *testing.T) {
+	// {"Huge": 2^64-1, "Max": 2^63-1}, Huge is stored as a 128 bit integer
+	content, err := hex.DecodeString("62706c6973743030d2010203045448756765534d6178140000000000000000ffffffffffffffff137fffffffffffffff080d1216270000000000000101000000000000000500000000000000000000000000000030")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := NewDataFromContent(content)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, ok := data["Huge"].(uint64); !ok || got != math.MaxUint64 {
+		t.Errorf("Huge: got (%v, %T)", data["Huge"], data["Huge"])
+	}
+	if got, ok := data.GetInt("Max"); !ok || got != math.MaxInt64 {
+		t.Errorf("Max: got (%v, %v)", got, ok)
+	}
+}
+
+func TestNewDataFromContentErrors(t *testing.T) {
+	xmlContent := readFixture(t, "Info.xml.plist")
+	binaryContent := readFixture(t, "Info.binary.plist")
+
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"empty", []byte{}},
+		{"unknown format", []byte("CFBundleIdentifier = com.example;")},
+		{"binary header only", []byte(binaryHeader)},
+		{"xml without plist element", []byte(`<?xml version="1.0"?><dict></dict>`)},
+		{"xml array root", []byte(`<plist><array><string>a</string></array></plist>`)},
+		{"xml dict value without key", []byte(`<plist><dict><string>a</string></dict></plist>`)},
+		{"xml invalid integer", []byte(`<plist><dict><key>a</key><integer>x</integer></dict></plist>`)},
+		{"xml unknown element", []byte(`<plist><dict><key>a</key><number>1</number></dict></plist>`)},
+		{"xml truncated", xmlContent[:len(xmlContent)/2]},
+		{"binary truncated trailer", binaryContent[:len(binaryContent)-8]},
+		{"binary truncated objects", append(append([]byte{}, binaryContent[:len(binaryHeader)+4]...), binaryContent[len(binaryContent)-binaryTrailerSize:]...)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := NewDataFromContent(test.content); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
+
+func TestParseBinaryReferenceCycle(t *testing.T) {
+	// an array, which refers to itself
+	content := []byte(binaryHeader)
+	content = append(content, 0xa1, 0x00) // object 0 at offset 8: array of 1 ref: object 0
+	content = append(content, 0x08)       // offset table at offset 10
+	trailer := make([]byte, binaryTrailerSize)
+	trailer[6] = 1  // offset int size
+	trailer[7] = 1  // object ref size
+	trailer[15] = 1 // number of objects
+	trailer[31] = 10
+	content = append(content, trailer...)
+
+	if _, err := Parse(content); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+// TestParseCorruptContent checks that truncated and corrupted property lists return errors instead of panicking.
+func TestParseCorruptContent(t *testing.T) {
+	for _, fixture := range []string{"Info.xml.plist", "Info.binary.plist"} {
+		content := readFixture(t, fixture)
+
+		// the parser stops at the end of the root dictionary, the closing plist element is not required
+		end := len(content)
+		if idx := bytes.LastIndex(content, []byte("</dict>")); idx != -1 {
+			end = idx + len("</dict>")
+		}
+
+		for length := 0; length < end; length++ {
+			if err := parseWithoutPanic(t, fixture, content[:length]); err == nil {
+				t.Errorf("%s: expected error for content truncated to %d bytes", fixture, length)
+			}
+		}
+
+		for i := range content {
+			for _, value := range []byte{0x00, 0x0f, 0x7f, 0xff} {
+				corrupt := append([]byte{}, content...)
+				corrupt[i] = value
+				parseWithoutPanic(t, fixture, corrupt)
+			}
+		}
+	}
+}
+
+func parseWithoutPanic(t *testing.T, fixture string, content []byte) error {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("%s: panic on %x: %v", fixture, content, r)
+		}
+	}()
+
+	_, err := Parse(content)
+	return err
+}
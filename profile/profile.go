@@ -0,0 +1,159 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/plist"
+)
+
+// Type ...
+type Type string
+
+const (
+	// TypeDevelopment ...
+	TypeDevelopment Type = "development"
+	// TypeAdHoc ...
+	TypeAdHoc Type = "ad-hoc"
+	// TypeEnterprise ...
+	TypeEnterprise Type = "enterprise"
+	// TypeAppStore ...
+	TypeAppStore Type = "app-store"
+)
+
+// Model ...
+type Model struct {
+	Name           string
+	UUID           string
+	TeamID         string
+	TeamName       string
+	Type           Type
+	CreationDate   time.Time
+	ExpirationDate time.Time
+	Entitlements   plist.Data
+	DeviceCount    int
+}
+
+var oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo encapContentInfo
+}
+
+type encapContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+// Content extracts the property list from the CMS (PKCS#7) envelope of a provisioning profile.
+func Content(mobileprovision []byte) ([]byte, error) {
+	var info contentInfo
+	if _, err := asn1.Unmarshal(mobileprovision, &info); err == nil && info.ContentType.Equal(oidSignedData) {
+		var signed signedData
+		if _, err := asn1.Unmarshal(info.Content.Bytes, &signed); err == nil && len(signed.EncapContentInfo.Content) > 0 {
+			return signed.EncapContentInfo.Content, nil
+		}
+	}
+
+	// BER encoded envelopes (indefinite lengths, chunked octet strings) are not supported by encoding/asn1,
+	// but the signed content is an XML plist embedded as is.
+	start := bytes.Index(mobileprovision, []byte("<?xml"))
+	endTag := []byte("</plist>")
+	end := bytes.LastIndex(mobileprovision, endTag)
+	if start == -1 || end == -1 || end < start {
+		return nil, fmt.Errorf("no property list found in provisioning profile")
+	}
+	return mobileprovision[start : end+len(endTag)], nil
+}
+
+// NewFromContent parses an embedded.mobileprovision file.
+func NewFromContent(mobileprovision []byte) (Model, error) {
+	content, err := Content(mobileprovision)
+	if err != nil {
+		return Model{}, err
+	}
+
+	data, err := plist.NewDataFromContent(content)
+	if err != nil {
+		return Model{}, fmt.Errorf("failed to parse provisioning profile, error: %s", err)
+	}
+
+	model := Model{}
+	model.Name, _ = data.GetString("Name")
+	model.UUID, _ = data.GetString("UUID")
+	model.TeamName, _ = data.GetString("TeamName")
+	model.CreationDate, _ = data.GetTime("CreationDate")
+	model.ExpirationDate, _ = data.GetTime("ExpirationDate")
+	if teamIDs, ok := data.GetStringArray("TeamIdentifier"); ok && len(teamIDs) > 0 {
+		model.TeamID = teamIDs[0]
+	}
+
+	model.Entitlements, _ = data.GetData("Entitlements")
+	if model.Entitlements == nil {
+		model.Entitlements = plist.Data{}
+	}
+
+	devices, hasDevices := data.GetStringArray("ProvisionedDevices")
+	model.DeviceCount = len(devices)
+	allDevices, _ := data.GetBool("ProvisionsAllDevices")
+	getTaskAllow, _ := model.Entitlements.GetBool("get-task-allow")
+
+	switch {
+	case allDevices:
+		model.Type = TypeEnterprise
+	case hasDevices && getTaskAllow:
+		model.Type = TypeDevelopment
+	case hasDevices:
+		model.Type = TypeAdHoc
+	default:
+		model.Type = TypeAppStore
+	}
+
+	return model, nil
+}
+
+// Validate checks if an app signed with the profile can be installed on Test Cloud devices.
+func (model Model) Validate(now time.Time) error {
+	if !model.ExpirationDate.IsZero() && model.ExpirationDate.Before(now) {
+		return fmt.Errorf("provisioning profile (%s) expired at %s", model.Name, model.ExpirationDate.Format(time.RFC3339))
+	}
+	if model.Type == TypeAppStore {
+		return fmt.Errorf("provisioning profile (%s) is an App Store profile, apps signed with it can not be installed on test devices, use a development, ad-hoc or enterprise profile", model.Name)
+	}
+	return nil
+}
+
+// EntitlementList returns the entitlements as sorted key: value lines.
+func (model Model) EntitlementList() []string {
+	keys := []string{}
+	for key := range model.Entitlements {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	list := []string{}
+	for _, key := range keys {
+		value := model.Entitlements[key]
+		if values, ok := value.([]interface{}); ok {
+			strs := []string{}
+			for _, v := range values {
+				strs = append(strs, fmt.Sprintf("%v", v))
+			}
+			list = append(list, fmt.Sprintf("%s: [%s]", key, strings.Join(strs, ", ")))
+		} else {
+			list = append(list, fmt.Sprintf("%s: %v", key, value))
+		}
+	}
+	return list
+}
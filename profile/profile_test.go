@@ -0,0 +1,181 @@
+package profile
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %s", err)
+	}
+	return content
+}
+
+func TestNewFromContent(t *testing.T) {
+	tests := []struct {
+		fixture         string
+		wantName        string
+		wantType        Type
+		wantDeviceCount int
+		wantEntitlement string
+	}{
+		{"development.mobileprovision", "Sample development", TypeDevelopment, 2, "get-task-allow: true"},
+		{"ad-hoc.mobileprovision", "Sample adhoc", TypeAdHoc, 2, "get-task-allow: false"},
+		{"enterprise.mobileprovision", "Sample enterprise", TypeEnterprise, 0, "get-task-allow: false"},
+		{"app-store.mobileprovision", "Sample appstore", TypeAppStore, 0, "get-task-allow: false"},
+		{"ad-hoc.ber.mobileprovision", "Sample adhoc", TypeAdHoc, 2, "get-task-allow: false"},
+		{"no-xml-declaration.mobileprovision", "Sample nodecl", TypeDevelopment, 2, "get-task-allow: true"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			model, err := NewFromContent(readFixture(t, test.fixture))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if model.Name != test.wantName {
+				t.Errorf("name: got %q, want %q", model.Name, test.wantName)
+			}
+			if model.Type != test.wantType {
+				t.Errorf("type: got %s, want %s", model.Type, test.wantType)
+			}
+			if model.DeviceCount != test.wantDeviceCount {
+				t.Errorf("device count: got %d, want %d", model.DeviceCount, test.wantDeviceCount)
+			}
+			if model.UUID != "6F2A1B3C-4D5E-4F60-8A71-92B3C4D5E6F7" {
+				t.Errorf("uuid: got %q", model.UUID)
+			}
+			if model.TeamID != "ABCDE12345" || model.TeamName != "Bitrise Sample Team" {
+				t.Errorf("team: got %q (%q)", model.TeamName, model.TeamID)
+			}
+			if want := time.Date(2027, 1, 10, 9, 30, 0, 0, time.UTC); !model.ExpirationDate.Equal(want) {
+				t.Errorf("expiration date: got %s, want %s", model.ExpirationDate, want)
+			}
+
+			entitlements := model.EntitlementList()
+			found := false
+			for _, entitlement := range entitlements {
+				if entitlement == test.wantEntitlement {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("entitlements: got %v, want %q", entitlements, test.wantEntitlement)
+			}
+		})
+	}
+}
+
+func TestContent(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    []byte
+		wantPrefix string
+		wantErr    bool
+	}{
+		// the DER encoded CMS envelope is parsed, the content does not need an XML declaration
+		{"cms", readFixture(t, "no-xml-declaration.mobileprovision"), "<!DOCTYPE plist", false},
+		// the BER encoded (indefinite length) envelope falls back to the embedded XML
+		{"ber", readFixture(t, "ad-hoc.ber.mobileprovision"), "<?xml", false},
+		{"plain xml", []byte(`<?xml version="1.0"?><plist version="1.0"><dict/></plist>`), "<?xml", false},
+		{"empty", []byte{}, "", true},
+		{"garbage", []byte("not a provisioning profile"), "", true},
+		{"truncated", readFixture(t, "ad-hoc.mobileprovision")[:600], "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content, err := Content(test.content)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got content: %q", content)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !bytes.HasPrefix(content, []byte(test.wantPrefix)) {
+				t.Errorf("got content starting with %q, want %q", content[:20], test.wantPrefix)
+			}
+			if !bytes.HasSuffix(bytes.TrimSpace(content), []byte("</plist>")) {
+				t.Errorf("got content ending with %q, want </plist>", content[len(content)-20:])
+			}
+		})
+	}
+}
+
+func TestNewFromContentMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr string
+	}{
+		{"no property list", []byte("not a provisioning profile"), "no property list found"},
+		{"invalid property list", []byte(`<?xml version="1.0"?><plist version="1.0"><array><string>Sample</string></array></plist>`), "failed to parse provisioning profile"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewFromContent(test.content)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("error: got %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		model   Model
+		wantErr string
+	}{
+		{"development", Model{Name: "Dev", Type: TypeDevelopment, ExpirationDate: now.AddDate(0, 1, 0)}, ""},
+		{"no expiration date", Model{Name: "Enterprise", Type: TypeEnterprise}, ""},
+		{"expired", Model{Name: "AdHoc", Type: TypeAdHoc, ExpirationDate: now.AddDate(0, 0, -1)}, "provisioning profile (AdHoc) expired"},
+		{"app store", Model{Name: "Store", Type: TypeAppStore, ExpirationDate: now.AddDate(0, 1, 0)}, "is an App Store profile"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.model.Validate(now)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("error: got %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestEntitlementList(t *testing.T) {
+	model, err := NewFromContent(readFixture(t, "development.mobileprovision"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{
+		"application-identifier: ABCDE12345.io.bitrise.sample",
+		"get-task-allow: true",
+		"keychain-access-groups: [ABCDE12345.*]",
+	}
+	if got := model.EntitlementList(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
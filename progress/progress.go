@@ -0,0 +1,251 @@
+package progress
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phase ...
+type Phase string
+
+const (
+	// PhaseUnknown ...
+	PhaseUnknown Phase = "unknown"
+	// PhaseValidating ...
+	PhaseValidating Phase = "validating"
+	// PhaseUploading ...
+	PhaseUploading Phase = "uploading"
+	// PhaseUploaded ...
+	PhaseUploaded Phase = "uploaded"
+	// PhaseQueued ...
+	PhaseQueued Phase = "queued"
+	// PhaseRunning ...
+	PhaseRunning Phase = "running"
+	// PhaseResults ...
+	PhaseResults Phase = "results"
+)
+
+// EventType ...
+type EventType string
+
+const (
+	// EventPhaseStarted ...
+	EventPhaseStarted EventType = "phase-started"
+	// EventUploadProgress ...
+	EventUploadProgress EventType = "upload-progress"
+	// EventFailure ...
+	EventFailure EventType = "failure"
+)
+
+// Event ...
+type Event struct {
+	Type  EventType
+	Phase Phase
+	Time  time.Time
+	Line  string
+
+	// Percent is set for EventUploadProgress.
+	Percent int
+	// DeviceCount is set for PhaseRunning, if test-cloud.exe printed it.
+	DeviceCount int
+	// Failure is set for EventFailure.
+	Failure string
+}
+
+// Callback ...
+type Callback func(event Event)
+
+// Timing ...
+type Timing struct {
+	Phase    Phase
+	Duration time.Duration
+}
+
+type phasePattern struct {
+	phase Phase
+	re    *regexp.Regexp
+}
+
+// Checked in order, the first match wins.
+var phasePatterns = []phasePattern{
+	{PhaseUploaded, regexp.MustCompile(`(?i)^upload(ing)?\s+(complete|completed|finished|done)`)},
+	{PhaseUploading, regexp.MustCompile(`(?i)^uploading\b`)},
+	{PhaseValidating, regexp.MustCompile(`(?i)^(negotiating file upload|validating\b|verifying\b)`)},
+	{PhaseQueued, regexp.MustCompile(`(?i)(tests?( run)? (enqueued|queued)|^test run id:)`)},
+	{PhaseRunning, regexp.MustCompile(`(?i)^(running on|waiting for|running tests)\b`)},
+	{PhaseResults, regexp.MustCompile(`(?i)^(test report:|done\.|test results?:|\d+ (tests? )?passed)`)},
+}
+
+var (
+	// the progress lines of the upload: "Uploading 25%", "Uploading Sample.ipa (25%)", "Uploading 25.5 %..."
+	uploadPercentPattern = regexp.MustCompile(`(?i)^uploading\b[^%]*?\b(\d{1,3})(?:\.\d+)?\s*%\)?\.*$`)
+	deviceCountPattern   = regexp.MustCompile(`(?i)(\d+) devices?`)
+)
+
+var failurePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^error:\s*(.+)$`),
+	regexp.MustCompile(`(?i)(?:invalid api key|unauthorized)`),
+	regexp.MustCompile(`(?i)(?:not linked with|does not contain) .*(?:calabash|test cloud agent)`),
+	regexp.MustCompile(`(?i)(?:unknown|invalid) device (?:selection|set)`),
+	regexp.MustCompile(`(?i)^unhandled exception:?\s*(.+)$`),
+	regexp.MustCompile(`(?i)^submission failed:?\s*(.+)$`),
+}
+
+// Parser turns test-cloud.exe submit output lines into Events.
+type Parser struct {
+	callback Callback
+	now      func() time.Time
+
+	mutex         sync.Mutex
+	phase         Phase
+	phaseStart    time.Time
+	uploadPercent int
+	timings       []Timing
+	failures      []string
+}
+
+// NewParser ...
+func NewParser(callback Callback) *Parser {
+	return &Parser{
+		callback:      callback,
+		now:           time.Now,
+		phase:         PhaseUnknown,
+		uploadPercent: -1,
+	}
+}
+
+// Phase returns the last recognized phase.
+func (parser *Parser) Phase() Phase {
+	parser.mutex.Lock()
+	defer parser.mutex.Unlock()
+
+	return parser.phase
+}
+
+// Failures returns the well-known failure messages found so far.
+func (parser *Parser) Failures() []string {
+	parser.mutex.Lock()
+	defer parser.mutex.Unlock()
+
+	return append([]string{}, parser.failures...)
+}
+
+// ParseLine processes a single output line.
+// It returns true if the line only reported upload progress, so the caller can omit printing it.
+func (parser *Parser) ParseLine(line string) bool {
+	parser.mutex.Lock()
+	events, progressOnly := parser.parseLine(line)
+	parser.mutex.Unlock()
+
+	if parser.callback != nil {
+		for _, event := range events {
+			parser.callback(event)
+		}
+	}
+
+	return progressOnly
+}
+
+// Finish closes the current phase and returns the duration of every phase seen.
+func (parser *Parser) Finish() []Timing {
+	parser.mutex.Lock()
+	defer parser.mutex.Unlock()
+
+	parser.closePhase(parser.now())
+	parser.phase = PhaseUnknown
+
+	return append([]Timing{}, parser.timings...)
+}
+
+func (parser *Parser) parseLine(line string) ([]Event, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return nil, false
+	}
+
+	now := parser.now()
+	events := []Event{}
+
+	for _, re := range failurePatterns {
+		if matches := re.FindStringSubmatch(trimmed); len(matches) > 0 {
+			failure := trimmed
+			if len(matches) > 1 && re.NumSubexp() == 1 && matches[1] != "" {
+				failure = matches[1]
+			}
+			parser.failures = append(parser.failures, failure)
+			events = append(events, Event{Type: EventFailure, Phase: parser.phase, Time: now, Line: line, Failure: failure})
+			return events, false
+		}
+	}
+
+	if matches := uploadPercentPattern.FindStringSubmatch(trimmed); len(matches) == 2 {
+		percent, err := strconv.Atoi(matches[1])
+		if err == nil && percent <= 100 {
+			if parser.phase != PhaseUploading {
+				events = append(events, parser.startPhase(PhaseUploading, now, line, 0))
+			}
+			if percent > parser.uploadPercent {
+				parser.uploadPercent = percent
+				events = append(events, Event{Type: EventUploadProgress, Phase: PhaseUploading, Time: now, Line: line, Percent: percent})
+			}
+			return events, true
+		}
+	}
+
+	for _, pattern := range phasePatterns {
+		if !pattern.re.MatchString(trimmed) {
+			continue
+		}
+
+		deviceCount := 0
+		if pattern.phase == PhaseRunning {
+			if matches := deviceCountPattern.FindStringSubmatch(trimmed); len(matches) == 2 {
+				deviceCount, _ = strconv.Atoi(matches[1])
+			}
+		}
+
+		if pattern.phase != parser.phase || deviceCount > 0 {
+			events = append(events, parser.startPhase(pattern.phase, now, line, deviceCount))
+		}
+		break
+	}
+
+	return events, false
+}
+
+func (parser *Parser) startPhase(phase Phase, now time.Time, line string, deviceCount int) Event {
+	if phase != parser.phase {
+		parser.closePhase(now)
+		parser.phase = phase
+		parser.phaseStart = now
+	}
+
+	return Event{Type: EventPhaseStarted, Phase: phase, Time: now, Line: line, DeviceCount: deviceCount}
+}
+
+func (parser *Parser) closePhase(now time.Time) {
+	if parser.phase == PhaseUnknown {
+		return
+	}
+
+	duration := now.Sub(parser.phaseStart)
+	for i, timing := range parser.timings {
+		if timing.Phase == parser.phase {
+			parser.timings[i].Duration += duration
+			return
+		}
+	}
+	parser.timings = append(parser.timings, Timing{Phase: parser.phase, Duration: duration})
+}
+
+// FormatTimings ...
+func FormatTimings(timings []Timing) string {
+	lines := []string{}
+	for _, timing := range timings {
+		lines = append(lines, string(timing.Phase)+": "+(timing.Duration/time.Second*time.Second).String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,208 @@
+package progress
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestParser returns a parser, whose clock advances 10 seconds at every read.
+func newTestParser(events *[]Event) *Parser {
+	parser := NewParser(func(event Event) {
+		*events = append(*events, event)
+	})
+
+	tick := 0
+	parser.now = func() time.Time {
+		tick++
+		return start.Add(time.Duration(tick) * 10 * time.Second)
+	}
+	return parser
+}
+
+// readTranscript reads a test-cloud.exe submit transcript of testdata.
+// The transcripts are reconstructed from the output format of test-cloud.exe, not captured from a submission,
+// replace them with captured transcripts when one is at hand.
+func readTranscript(t *testing.T, name string) []string {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read transcript: %s", err)
+	}
+	return strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+}
+
+type eventSummary struct {
+	Type        EventType
+	Phase       Phase
+	Percent     int
+	DeviceCount int
+	Failure     string
+}
+
+func summarize(events []Event) []eventSummary {
+	summaries := []eventSummary{}
+	for _, event := range events {
+		summaries = append(summaries, eventSummary{event.Type, event.Phase, event.Percent, event.DeviceCount, event.Failure})
+	}
+	return summaries
+}
+
+func TestParserSubmitTranscript(t *testing.T) {
+	events := []Event{}
+	parser := newTestParser(&events)
+
+	progressOnly := []bool{}
+	for _, line := range readTranscript(t, "submit.txt") {
+		progressOnly = append(progressOnly, parser.ParseLine(line))
+	}
+
+	wantProgressOnly := []bool{false, false, true, true, true, true, false, false, false, false, false, false}
+	if !reflect.DeepEqual(progressOnly, wantProgressOnly) {
+		t.Errorf("progress only:\ngot:  %v\nwant: %v", progressOnly, wantProgressOnly)
+	}
+
+	wantEvents := []eventSummary{
+		{Type: EventPhaseStarted, Phase: PhaseValidating},
+		{Type: EventPhaseStarted, Phase: PhaseUploading},
+		{Type: EventUploadProgress, Phase: PhaseUploading, Percent: 25},
+		{Type: EventUploadProgress, Phase: PhaseUploading, Percent: 50},
+		{Type: EventUploadProgress, Phase: PhaseUploading, Percent: 100},
+		{Type: EventPhaseStarted, Phase: PhaseUploaded},
+		{Type: EventPhaseStarted, Phase: PhaseQueued},
+		{Type: EventPhaseStarted, Phase: PhaseRunning, DeviceCount: 3},
+		{Type: EventPhaseStarted, Phase: PhaseResults},
+	}
+	if got := summarize(events); !reflect.DeepEqual(got, wantEvents) {
+		t.Errorf("events:\ngot:  %+v\nwant: %+v", got, wantEvents)
+	}
+
+	if phase := parser.Phase(); phase != PhaseResults {
+		t.Errorf("phase: got %s, want %s", phase, PhaseResults)
+	}
+	if failures := parser.Failures(); len(failures) != 0 {
+		t.Errorf("failures: got %v, want none", failures)
+	}
+
+	wantTimings := []Timing{
+		{PhaseValidating, 10 * time.Second},
+		{PhaseUploading, 50 * time.Second},
+		{PhaseUploaded, 10 * time.Second},
+		{PhaseQueued, 10 * time.Second},
+		{PhaseRunning, 20 * time.Second},
+		{PhaseResults, 20 * time.Second},
+	}
+	if timings := parser.Finish(); !reflect.DeepEqual(timings, wantTimings) {
+		t.Errorf("timings:\ngot:  %v\nwant: %v", timings, wantTimings)
+	}
+	if phase := parser.Phase(); phase != PhaseUnknown {
+		t.Errorf("phase after finish: got %s, want %s", phase, PhaseUnknown)
+	}
+}
+
+func TestParserFailedTranscript(t *testing.T) {
+	events := []Event{}
+	parser := newTestParser(&events)
+
+	for _, line := range readTranscript(t, "submit_failed.txt") {
+		if parser.ParseLine(line) {
+			t.Errorf("line reported as progress only: %s", line)
+		}
+	}
+
+	wantEvents := []eventSummary{
+		{Type: EventPhaseStarted, Phase: PhaseValidating},
+		{Type: EventFailure, Phase: PhaseValidating, Failure: "Invalid API key"},
+		{Type: EventFailure, Phase: PhaseValidating, Failure: "System.Exception: Submission rejected"},
+	}
+	if got := summarize(events); !reflect.DeepEqual(got, wantEvents) {
+		t.Errorf("events:\ngot:  %+v\nwant: %+v", got, wantEvents)
+	}
+
+	wantFailures := []string{"Invalid API key", "System.Exception: Submission rejected"}
+	if failures := parser.Failures(); !reflect.DeepEqual(failures, wantFailures) {
+		t.Errorf("failures:\ngot:  %v\nwant: %v", failures, wantFailures)
+	}
+
+	// the empty line does not read the clock, the failures do not close the phase
+	wantTimings := []Timing{{PhaseValidating, 30 * time.Second}}
+	if timings := parser.Finish(); !reflect.DeepEqual(timings, wantTimings) {
+		t.Errorf("timings:\ngot:  %v\nwant: %v", timings, wantTimings)
+	}
+}
+
+func TestParserFailureLines(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"Error: Invalid API key", "Invalid API key"},
+		{"The remote server returned an error: (401) Unauthorized.", "The remote server returned an error: (401) Unauthorized."},
+		{"The app is not linked with the Test Cloud Agent", "The app is not linked with the Test Cloud Agent"},
+		{"Invalid device selection: abc123", "Invalid device selection: abc123"},
+		{"Submission failed: timeout", "timeout"},
+	}
+
+	for _, tt := range tests {
+		events := []Event{}
+		parser := newTestParser(&events)
+		parser.ParseLine(tt.line)
+
+		if failures := parser.Failures(); !reflect.DeepEqual(failures, []string{tt.want}) {
+			t.Errorf("%q: got %v, want [%s]", tt.line, failures, tt.want)
+		}
+	}
+}
+
+func TestParserUploadProgressLines(t *testing.T) {
+	tests := []struct {
+		line        string
+		wantPercent int
+	}{
+		{"Uploading 25%", 25},
+		{"uploading 100 %", 100},
+		{"Uploading Sample.ipa (42%)", 42},
+		{"Uploading 12.5%...", 12},
+		// not upload progress
+		{"Uploading 4.45 MB of files.", -1},
+		{"Upload complete, 100% of the files are uploaded", -1},
+		{"Running on 3 devices, 50% done", -1},
+		{"Uploading 250%", -1},
+	}
+
+	for _, tt := range tests {
+		events := []Event{}
+		parser := newTestParser(&events)
+		progressOnly := parser.ParseLine(tt.line)
+
+		percent := -1
+		for _, event := range events {
+			if event.Type == EventUploadProgress {
+				percent = event.Percent
+			}
+		}
+
+		if percent != tt.wantPercent {
+			t.Errorf("%q: got percent %d, want %d", tt.line, percent, tt.wantPercent)
+		}
+		if progressOnly != (tt.wantPercent != -1) {
+			t.Errorf("%q: progress only: got %t, want %t", tt.line, progressOnly, tt.wantPercent != -1)
+		}
+	}
+}
+
+func TestFormatTimings(t *testing.T) {
+	timings := []Timing{
+		{PhaseUploading, 42*time.Second + 300*time.Millisecond},
+		{PhaseRunning, 3 * time.Minute},
+	}
+
+	want := "uploading: 42s\nrunning: 3m0s"
+	if got := FormatTimings(timings); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
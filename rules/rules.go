@@ -0,0 +1,144 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/devices"
+)
+
+// Trigger ...
+type Trigger string
+
+const (
+	// TriggerPR ...
+	TriggerPR Trigger = "pr"
+	// TriggerPush ...
+	TriggerPush Trigger = "push"
+)
+
+// Model is a device set selection rule, its empty conditions match any build.
+type Model struct {
+	Name       string   `json:"name,omitempty"`
+	Branch     string   `json:"branch,omitempty"`
+	Workflow   string   `json:"workflow,omitempty"`
+	Trigger    Trigger  `json:"trigger,omitempty"`
+	Devices    string   `json:"devices"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+// EnvironmentModel describes the build the rules are evaluated against.
+type EnvironmentModel struct {
+	Branch   string
+	Workflow string
+	Trigger  Trigger
+}
+
+// String returns the name of the rule, or its conditions if the rule is not named.
+func (rule Model) String() string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+
+	conditions := []string{}
+	if rule.Branch != "" {
+		conditions = append(conditions, "branch: "+rule.Branch)
+	}
+	if rule.Workflow != "" {
+		conditions = append(conditions, "workflow: "+rule.Workflow)
+	}
+	if rule.Trigger != "" {
+		conditions = append(conditions, "trigger: "+string(rule.Trigger))
+	}
+	if len(conditions) == 0 {
+		return "default"
+	}
+	return strings.Join(conditions, ", ")
+}
+
+// Matches reports whether every condition of the rule matches the build.
+func (rule Model) Matches(env EnvironmentModel) bool {
+	if rule.Branch != "" && !globMatch(rule.Branch, env.Branch) {
+		return false
+	}
+	if rule.Workflow != "" && !globMatch(rule.Workflow, env.Workflow) {
+		return false
+	}
+	if rule.Trigger != "" && rule.Trigger != env.Trigger {
+		return false
+	}
+	return true
+}
+
+// Parse parses a JSON list of rules, given inline or as a path to a JSON file.
+func Parse(value string) ([]Model, error) {
+	content := []byte(value)
+	if trimmed := strings.TrimSpace(value); !strings.HasPrefix(trimmed, "[") {
+		fileContent, err := ioutil.ReadFile(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("rules are neither a JSON list nor a readable file, error: %s", err)
+		}
+		content = fileContent
+	}
+
+	var rules []Model
+	if err := json.Unmarshal(content, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules, error: %s", err)
+	}
+
+	for i, rule := range rules {
+		if rule.Devices == "" {
+			return nil, fmt.Errorf("rule #%d: devices is not set", i+1)
+		}
+		if _, err := devices.ParseSelections(rule.Devices); err != nil {
+			return nil, fmt.Errorf("rule #%d: %s", i+1, err)
+		}
+		if rule.Trigger != "" && rule.Trigger != TriggerPR && rule.Trigger != TriggerPush {
+			return nil, fmt.Errorf("rule #%d: invalid trigger (%s), available: %s, %s", i+1, rule.Trigger, TriggerPR, TriggerPush)
+		}
+	}
+
+	return rules, nil
+}
+
+// Select returns the index of the first rule matching the build, or -1.
+func Select(rules []Model, env EnvironmentModel) int {
+	for i, rule := range rules {
+		if rule.Matches(env) {
+			return i
+		}
+	}
+	return -1
+}
+
+// SelectDevices returns the devices of the first rule matching the build and the index of the rule,
+// or the fallback devices and -1 if no rule matches.
+func SelectDevices(rules []Model, env EnvironmentModel, fallback string) (string, int) {
+	i := Select(rules, env)
+	if i == -1 {
+		return fallback, -1
+	}
+	return rules[i].Devices, i
+}
+
+// globMatch matches the value against a glob pattern, where '*' matches any characters (including '/')
+// and '?' matches a single character.
+func globMatch(pattern, value string) bool {
+	expression := "^"
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expression += ".*"
+		case '?':
+			expression += "."
+		default:
+			expression += regexp.QuoteMeta(string(r))
+		}
+	}
+	expression += "$"
+
+	return regexp.MustCompile(expression).MatchString(value)
+}
@@ -0,0 +1,117 @@
+package rules
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	want := []Model{
+		{Name: "release", Branch: "release/*", Devices: "phones=a1b2c3d4,tablets=e5f6a7b8"},
+		{Trigger: TriggerPR, Devices: "c9d0e1f2", Categories: []string{"Smoke"}},
+	}
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"inline", `[
+  {"name": "release", "branch": "release/*", "devices": "phones=a1b2c3d4,tablets=e5f6a7b8"},
+  {"trigger": "pr", "devices": "c9d0e1f2", "categories": ["Smoke"]}
+]`},
+		{"file", filepath.Join("testdata", "rules.json")},
+		{"file with whitespace", " " + filepath.Join("testdata", "rules.json") + "\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules, err := Parse(test.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(rules, want) {
+				t.Errorf("got %+v, want %+v", rules, want)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"missing file", filepath.Join("testdata", "missing.json"), "rules are neither a JSON list nor a readable file"},
+		{"invalid json", `[{"devices": "a1b2c3d4"`, "failed to parse rules"},
+		{"missing devices", `[{"branch": "master"}]`, "rule #1: devices is not set"},
+		{"invalid device id", `[{"devices": "a1b2c3d4"}, {"devices": "phones=a1b2c3d4,phones=e5f6a7b8"}]`, "rule #2: device selection (phones) is listed multiple times"},
+		{"invalid device label", `[{"devices": "my phones=a1b2c3d4"}]`, "rule #1: invalid device selection label (my phones)"},
+		{"empty device id", `[{"devices": "phones="}]`, "rule #1: invalid device selection: phones="},
+		{"invalid trigger", `[{"trigger": "tag", "devices": "a1b2c3d4"}]`, "rule #1: invalid trigger (tag)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(test.value)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("error: got %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestSelectDevices(t *testing.T) {
+	rules := []Model{
+		{Name: "release", Branch: "release/*", Devices: "release-devices"},
+		{Workflow: "nightly", Trigger: TriggerPush, Devices: "nightly-devices"},
+		{Trigger: TriggerPR, Devices: "pr-devices"},
+		{Branch: "feature/?", Devices: "feature-devices"},
+	}
+
+	tests := []struct {
+		name        string
+		rules       []Model
+		env         EnvironmentModel
+		wantDevices string
+		wantIndex   int
+	}{
+		{"branch glob", rules, EnvironmentModel{Branch: "release/1.2/hotfix", Trigger: TriggerPR}, "release-devices", 0},
+		{"first matching rule takes precedence", rules, EnvironmentModel{Branch: "release/1.2", Workflow: "nightly", Trigger: TriggerPush}, "release-devices", 0},
+		{"every condition matches", rules, EnvironmentModel{Branch: "master", Workflow: "nightly", Trigger: TriggerPush}, "nightly-devices", 1},
+		{"workflow without trigger", rules, EnvironmentModel{Branch: "master", Workflow: "nightly", Trigger: TriggerPR}, "pr-devices", 2},
+		{"single character glob", rules, EnvironmentModel{Branch: "feature/x", Trigger: TriggerPush}, "feature-devices", 3},
+		{"single character glob does not match more", rules, EnvironmentModel{Branch: "feature/xy", Trigger: TriggerPush}, "input-devices", -1},
+		{"no match falls back to the devices input", rules, EnvironmentModel{Branch: "master", Workflow: "primary", Trigger: TriggerPush}, "input-devices", -1},
+		{"no rules", nil, EnvironmentModel{Branch: "master"}, "input-devices", -1},
+		{"rule without conditions", []Model{{Devices: "default-devices"}}, EnvironmentModel{Branch: "master"}, "default-devices", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			devices, i := SelectDevices(test.rules, test.env, "input-devices")
+			if devices != test.wantDevices || i != test.wantIndex {
+				t.Errorf("got (%s, %d), want (%s, %d)", devices, i, test.wantDevices, test.wantIndex)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		rule Model
+		want string
+	}{
+		{Model{Name: "release", Branch: "release/*"}, "release"},
+		{Model{Branch: "release/*", Workflow: "deploy", Trigger: TriggerPush}, "branch: release/*, workflow: deploy, trigger: push"},
+		{Model{}, "default"},
+	}
+
+	for _, test := range tests {
+		if got := test.rule.String(); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+}
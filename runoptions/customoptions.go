@@ -0,0 +1,153 @@
+package runoptions
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// --test-params key:value, --test-params="key:value", --test-params:'key:value'
+var customTestParamPattern = regexp.MustCompile(`(--test-params(?:[=:]|\s+))("[^"]*"|'[^']*'|[^\s"']+)`)
+
+// FlagModel describes a test-cloud.exe submit option.
+type FlagModel struct {
+	Name       string
+	TakesValue bool
+}
+
+// Flags are the known test-cloud.exe submit options.
+var Flags = []FlagModel{
+	{"--app-name", true},
+	{"--assembly-dir", true},
+	{"--async", false},
+	{"--async-json", false},
+	{"--category", true},
+	{"--data", true},
+	{"--debug", false},
+	{"--devices", true},
+	{"--dsym", true},
+	{"--exclude", true},
+	{"--fixture", true},
+	{"--fixture-chunk", false},
+	{"--include", true},
+	{"--locale", true},
+	{"--nunit-xml", true},
+	{"--series", true},
+	{"--sign-info", true},
+	{"--test-chunk", false},
+	{"--test-params", true},
+	{"--user", true},
+}
+
+func lookupFlag(name string) (FlagModel, bool) {
+	for _, flag := range Flags {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	return FlagModel{}, false
+}
+
+// StripComments removes the # comments of a multi-line option list,
+// a # starts a comment at the beginning of a word, outside of quotes.
+func StripComments(value string) string {
+	lines := []string{}
+	for _, line := range strings.Split(value, "\n") {
+		lines = append(lines, stripLineComment(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func stripLineComment(line string) string {
+	var quote rune
+	escaped := false
+	wordStart := true
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '#' && wordStart:
+			return line[:i]
+		}
+		wordStart = quote == 0 && !escaped && (r == ' ' || r == '\t')
+	}
+	return line
+}
+
+// ValidateCustomOptions checks the custom options against the known test-cloud.exe options.
+// It returns an error if an option set by the step (stepFlags) is given,
+// and returns the unknown options and unexpected arguments, which are passed as they are.
+func ValidateCustomOptions(options []string, stepFlags []string) ([]string, error) {
+	setByStep := map[string]bool{}
+	for _, flag := range stepFlags {
+		setByStep[flag] = true
+	}
+
+	conflicts := []string{}
+	unknown := []string{}
+	for i := 0; i < len(options); i++ {
+		option := options[i]
+		if !strings.HasPrefix(option, "-") {
+			unknown = append(unknown, option)
+			continue
+		}
+
+		// --name=value and --name:value
+		name := option
+		hasInlineValue := false
+		if idx := strings.IndexAny(option, "=:"); idx != -1 {
+			name = option[:idx]
+			hasInlineValue = true
+		}
+
+		flag, ok := lookupFlag(name)
+		if !ok {
+			unknown = append(unknown, name)
+			// the value of the unknown option, if any, is not reported separately
+			if !hasInlineValue && i+1 < len(options) && !strings.HasPrefix(options[i+1], "-") {
+				i++
+			}
+			continue
+		}
+
+		if setByStep[flag.Name] && !contains(conflicts, flag.Name) {
+			conflicts = append(conflicts, flag.Name)
+		}
+
+		if flag.TakesValue && !hasInlineValue {
+			if i+1 >= len(options) {
+				return nil, fmt.Errorf("option %s requires a value", flag.Name)
+			}
+			i++
+		}
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("options set by the step can not be given: %s", strings.Join(conflicts, ", "))
+	}
+	return unknown, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactCustomOptions replaces the --test-params values of the other_parameters input, for printing the input.
+func RedactCustomOptions(value string) string {
+	return customTestParamPattern.ReplaceAllString(value, "${1}"+redacted)
+}
@@ -0,0 +1,94 @@
+package runoptions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	testParamKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	// en_US, de-DE, zh_Hans_CN
+	localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}([_-][A-Za-z0-9]+)*$`)
+)
+
+// ParseTestParams parses a newline separated list of key:value pairs,
+// passed to the tests with test-cloud.exe's --test-params option.
+func ParseTestParams(value string) (map[string]string, error) {
+	params := map[string]string{}
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		split := strings.SplitN(line, ":", 2)
+		if len(split) != 2 {
+			return nil, fmt.Errorf("invalid test parameter (%s), should be key:value", redactedLine(line))
+		}
+
+		key, paramValue := strings.TrimSpace(split[0]), strings.TrimSpace(split[1])
+		if !testParamKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid test parameter key (%s), only letters, digits, '_', '.' and '-' are allowed", key)
+		}
+		if _, ok := params[key]; ok {
+			return nil, fmt.Errorf("test parameter (%s) is listed multiple times", key)
+		}
+
+		params[key] = paramValue
+	}
+	return params, nil
+}
+
+// redactedLine keeps the beginning of an invalid line, which may contain a secret, for the error message.
+func redactedLine(line string) string {
+	if len(line) <= 3 {
+		return redacted
+	}
+	return line[:3] + "..."
+}
+
+// ParseLocales parses a comma or newline separated list of locales.
+func ParseLocales(value string) ([]string, error) {
+	items := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' })
+
+	locales := []string{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		locale := strings.TrimSpace(item)
+		if locale == "" {
+			continue
+		}
+
+		if !localePattern.MatchString(locale) {
+			return nil, fmt.Errorf("invalid locale (%s), should be a language code with optional region, for example: en_US", locale)
+		}
+		if seen[locale] {
+			return nil, fmt.Errorf("locale (%s) is listed multiple times", locale)
+		}
+		seen[locale] = true
+
+		locales = append(locales, locale)
+	}
+	return locales, nil
+}
+
+// RedactTestParamsInput replaces the values of the key:value lines of the input, for printing the input.
+func RedactTestParamsInput(value string) string {
+	lines := []string{}
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if split := strings.SplitN(line, ":", 2); len(split) == 2 {
+			lines = append(lines, strings.TrimSpace(split[0])+":"+redacted)
+		} else {
+			lines = append(lines, redactedLine(line))
+		}
+	}
+	return strings.Join(lines, ", ")
+}
@@ -0,0 +1,136 @@
+package runoptions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	shellquote "github.com/kballard/go-shellquote"
+)
+
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"no comment", "--async --category Smoke", "--async --category Smoke"},
+		{"inline comment", "--category Smoke # only the smoke tests", "--category Smoke "},
+		{"comment after tab", "--async\t# do not wait", "--async\t"},
+		{"comment line", "# submit async\n--async", "\n--async"},
+		{"hash in double quotes", `--app-name "My #1 App"`, `--app-name "My #1 App"`},
+		{"hash in single quotes", `--app-name 'My #1 App' # name`, `--app-name 'My #1 App' `},
+		{"hash inside word", "--app-name foo#bar", "--app-name foo#bar"},
+		{"escaped hash", `--app-name \#1`, `--app-name \#1`},
+		{"escaped quote", `--app-name \"My #1 App\"`, `--app-name \"My `},
+		{
+			name:  "multi-line",
+			value: "--category Smoke # smoke\n\n# locale\n--locale \"en #US\"\n--async",
+			want:  "--category Smoke \n\n\n--locale \"en #US\"\n--async",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := StripComments(test.value); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStripCommentsSplit(t *testing.T) {
+	value := `--app-name "My #1 App" # the display name
+--data foo#bar
+# --async`
+
+	options, err := shellquote.Split(StripComments(value))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := []string{"--app-name", "My #1 App", "--data", "foo#bar"}; !reflect.DeepEqual(options, want) {
+		t.Errorf("got %q, want %q", options, want)
+	}
+}
+
+func TestValidateCustomOptions(t *testing.T) {
+	stepFlags := []string{"--devices", "--series", "--user"}
+
+	tests := []struct {
+		name        string
+		options     []string
+		wantUnknown []string
+	}{
+		{"no options", []string{}, []string{}},
+		{"known options", []string{"--async", "--category", "Smoke", "--app-name", "My #1 App"}, []string{}},
+		{"inline values", []string{"--category=Smoke", "--locale:en_US"}, []string{}},
+		{"value starting with dash", []string{"--exclude", "-Slow"}, []string{}},
+		{"unknown option", []string{"--verbose", "--async"}, []string{"--verbose"}},
+		{"unknown option with value", []string{"--retries", "3", "--async"}, []string{"--retries"}},
+		{"unknown option with inline value", []string{"--retries=3", "4"}, []string{"--retries", "4"}},
+		{"unexpected argument", []string{"--async", "extra"}, []string{"extra"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			unknown, err := ValidateCustomOptions(test.options, stepFlags)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(unknown, test.wantUnknown) {
+				t.Errorf("got %q, want %q", unknown, test.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestValidateCustomOptionsErrors(t *testing.T) {
+	stepFlags := []string{"--devices", "--series", "--user"}
+
+	tests := []struct {
+		name    string
+		options []string
+		wantErr string
+	}{
+		{"option set by the step", []string{"--devices", "a1b2c3d4"}, "options set by the step can not be given: --devices"},
+		{"option set by the step with inline value", []string{"--series=master"}, "options set by the step can not be given: --series"},
+		{"options set by the step sorted", []string{"--user", "a@b.c", "--devices", "a1b2c3d4", "--user", "d@e.f"}, "options set by the step can not be given: --devices, --user"},
+		{"missing value", []string{"--async", "--category"}, "option --category requires a value"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ValidateCustomOptions(test.options, stepFlags)
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("got error %q, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedactCustomOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"no test params", "--category Smoke --app-name \"My #1 App\"", "--category Smoke --app-name \"My #1 App\""},
+		{"separate value", "--test-params token:secret --async", "--test-params [REDACTED] --async"},
+		{"quoted value", "--test-params \"token:my secret\" --async", "--test-params [REDACTED] --async"},
+		{"single quoted value", "--test-params 'token:my secret'", "--test-params [REDACTED]"},
+		{"inline value", "--test-params=token:secret", "--test-params=[REDACTED]"},
+		{"colon separated inline value", "--test-params:token:secret", "--test-params:[REDACTED]"},
+		{"multi-line", "--test-params token:secret\n--test-params\nendpoint:staging", "--test-params [REDACTED]\n--test-params\n[REDACTED]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := RedactCustomOptions(test.value); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
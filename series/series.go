@@ -0,0 +1,81 @@
+package series
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DataModel holds the build metadata available in series templates.
+type DataModel struct {
+	Branch        string
+	PRNumber      string
+	BuildNumber   string
+	BundleVersion string
+	Configuration string
+}
+
+var (
+	invalidCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+	repeatedDashes    = regexp.MustCompile(`-{2,}`)
+)
+
+// Validate checks the syntax and the referred fields of the series template.
+func Validate(seriesTemplate string) error {
+	tmpl, err := parse(seriesTemplate)
+	if err != nil {
+		return err
+	}
+
+	if err := tmpl.Execute(ioutil.Discard, DataModel{}); err != nil {
+		return fmt.Errorf("invalid series template (%s), error: %s", seriesTemplate, err)
+	}
+	return nil
+}
+
+// Render executes the series template (for example: "{{.Branch}}-{{.BuildNumber}}").
+// The substituted values are sanitized to the characters Test Cloud accepts in series names,
+// the text of the template is kept as it is.
+func Render(seriesTemplate string, data DataModel) (string, error) {
+	tmpl, err := parse(seriesTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	sanitized := DataModel{
+		Branch:        Sanitize(data.Branch),
+		PRNumber:      Sanitize(data.PRNumber),
+		BuildNumber:   Sanitize(data.BuildNumber),
+		BundleVersion: Sanitize(data.BundleVersion),
+		Configuration: Sanitize(data.Configuration),
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, sanitized); err != nil {
+		return "", fmt.Errorf("failed to render series template (%s), error: %s", seriesTemplate, err)
+	}
+
+	series := buffer.String()
+	if strings.TrimSpace(series) == "" {
+		return "", fmt.Errorf("series template (%s) renders to an empty series name", seriesTemplate)
+	}
+	return series, nil
+}
+
+// Sanitize replaces the characters, which are not letters, digits, '.', '_' or '-', with '-'.
+func Sanitize(series string) string {
+	series = invalidCharacters.ReplaceAllString(series, "-")
+	series = repeatedDashes.ReplaceAllString(series, "-")
+	return strings.Trim(series, "-.")
+}
+
+func parse(seriesTemplate string) (*template.Template, error) {
+	tmpl, err := template.New("series").Option("missingkey=error").Parse(seriesTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid series template (%s), error: %s", seriesTemplate, err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,80 @@
+package series
+
+import (
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	data := DataModel{
+		Branch:        "feature/login screen",
+		PRNumber:      "",
+		BuildNumber:   "42",
+		BundleVersion: "1.2 (3)",
+		Configuration: "Release",
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		// templates without actions are used as they are
+		{"master", "master"},
+		{"My Series", "My Series"},
+		{"{{.Branch}}-{{.BuildNumber}}", "feature-login-screen-42"},
+		{"Nightly {{.Configuration}} #{{.BuildNumber}}", "Nightly Release #42"},
+		{"{{.BundleVersion}}", "1.2-3"},
+		{"PR {{.PRNumber}}", "PR "},
+		{"{{if .PRNumber}}PR {{.PRNumber}}{{else}}{{.Branch}}{{end}}", "feature-login-screen"},
+	}
+
+	for _, test := range tests {
+		got, err := Render(test.template, data)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.template, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.template, got, test.want)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	for _, template := range []string{
+		"{{.Branch",
+		"{{.Unknown}}",
+		"{{.PRNumber}}",
+		" {{.PRNumber}} ",
+	} {
+		if _, err := Render(template, DataModel{Branch: "master"}); err == nil {
+			t.Errorf("%s: expected error", template)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("{{.Branch}}-{{.BuildNumber}}"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := Validate("{{.Unknown}}"); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := map[string]string{
+		"master":             "master",
+		"feature/login":      "feature-login",
+		"a  //  b":           "a-b",
+		"-.release-1.0.":     "release-1.0",
+		"ünïcode":            "n-code",
+		"release_1.0-beta.2": "release_1.0-beta.2",
+		"/// ":               "",
+	}
+
+	for value, want := range tests {
+		if got := Sanitize(value); got != want {
+			t.Errorf("%q: got %q, want %q", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package symbolicate
+
+import (
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// crashLogExtensions are the extensions of the test case attachments handled as crash logs.
+var crashLogExtensions = []string{".crash", ".log", ".txt"}
+
+type attachmentModel struct {
+	FilePath string `xml:"filePath"`
+}
+
+// CrashLogAttachments returns the crash logs attached to the test cases of the NUnit 3 test result (<attachment><filePath>),
+// relative paths are resolved from resultDir.
+func CrashLogAttachments(resultLog, resultDir string) ([]string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(resultLog))
+
+	pths := []string{}
+	seen := map[string]bool{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		element, ok := token.(xml.StartElement)
+		if !ok || element.Name.Local != "attachment" {
+			continue
+		}
+
+		var attachment attachmentModel
+		if err := decoder.DecodeElement(&attachment, &element); err != nil {
+			return nil, err
+		}
+
+		pth := strings.TrimSpace(attachment.FilePath)
+		if pth == "" || !isCrashLog(pth) {
+			continue
+		}
+		if !filepath.IsAbs(pth) {
+			pth = filepath.Join(resultDir, pth)
+		}
+
+		if !seen[pth] {
+			seen[pth] = true
+			pths = append(pths, pth)
+		}
+	}
+
+	return pths, nil
+}
+
+func isCrashLog(pth string) bool {
+	ext := strings.ToLower(filepath.Ext(pth))
+	for _, crashLogExt := range crashLogExtensions {
+		if ext == crashLogExt {
+			return true
+		}
+	}
+	return false
+}
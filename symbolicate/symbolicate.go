@@ -0,0 +1,307 @@
+package symbolicate
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/dsym"
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/machoutil"
+)
+
+// LocationModel ...
+type LocationModel struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// String formats the location the way symbolicated crash reports do: function (file:line)
+func (location LocationModel) String() string {
+	if location.File == "" || location.Line == 0 {
+		return location.Function
+	}
+	return fmt.Sprintf("%s (%s:%d)", location.Function, filepath.Base(location.File), location.Line)
+}
+
+type addressRange struct {
+	low  uint64
+	high uint64
+	name string
+}
+
+type lineRow struct {
+	address     uint64
+	file        string
+	line        int
+	endSequence bool
+}
+
+type image struct {
+	textVMAddr uint64
+	functions  []addressRange // sorted by low
+	symbols    []addressRange // sorted by low, high is unknown
+	lines      []lineRow      // sorted by address
+}
+
+// Symbolicator maps offsets of the app executable to source locations using the DWARF data of a .dSYM bundle.
+type Symbolicator struct {
+	BinaryName string
+
+	images map[string]image // arch - image
+}
+
+var (
+	// 3   MyApp   0x00000001000f4a2c 0x1000e4000 + 68140
+	framePattern = regexp.MustCompile(`(?m)^(\s*\d+\s+)(\S+)(\s+0x[0-9a-fA-F]+\s+)(0x[0-9a-fA-F]+\s*\+\s*(\d+))`)
+	// MyApp + 68140, also right after an XML tag
+	imageOffsetPattern = regexp.MustCompile(`([^\s<>]+) \+ (\d+)\b`)
+	// Code Type:       ARM-64 (Native)
+	codeTypePattern = regexp.MustCompile(`(?m)^Code Type:\s*(\S+)`)
+)
+
+// New loads the DWARF data of every architecture of the .dSYM bundle.
+func New(dsymPth string) (*Symbolicator, error) {
+	binaryPth, err := dsym.DWARFBinaryPth(dsymPth)
+	if err != nil {
+		return nil, err
+	}
+
+	binary, err := dsym.Open(dsymPth)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := binary.Close(); err != nil {
+			log.Warnf("Failed to close dSYM, error: %s", err)
+		}
+	}()
+
+	symbolicator := &Symbolicator{
+		BinaryName: filepath.Base(binaryPth),
+		images:     map[string]image{},
+	}
+
+	for _, slice := range binary.Slices {
+		img, err := newImage(slice)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s DWARF data, error: %s", slice.Arch, err)
+		}
+		symbolicator.images[slice.Arch] = img
+	}
+
+	return symbolicator, nil
+}
+
+func newImage(slice machoutil.Slice) (image, error) {
+	img := image{}
+	if text := slice.File.Segment("__TEXT"); text != nil {
+		img.textVMAddr = text.Addr
+	}
+
+	if slice.File.Symtab != nil {
+		for _, symbol := range slice.File.Symtab.Syms {
+			// N_STAB debugging entries are not symbols
+			if symbol.Type&0xe0 != 0 || symbol.Value == 0 || symbol.Name == "" {
+				continue
+			}
+			img.symbols = append(img.symbols, addressRange{low: symbol.Value, name: strings.TrimPrefix(symbol.Name, "_")})
+		}
+		sort.Slice(img.symbols, func(i, j int) bool { return img.symbols[i].low < img.symbols[j].low })
+	}
+
+	data, err := slice.File.DWARF()
+	if err != nil {
+		// a stripped binary still has a symbol table to fall back to
+		if len(img.symbols) > 0 {
+			return img, nil
+		}
+		return image{}, err
+	}
+
+	reader := data.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return image{}, err
+		}
+		if entry == nil {
+			break
+		}
+
+		switch entry.Tag {
+		case dwarf.TagCompileUnit:
+			lineReader, err := data.LineReader(entry)
+			if err != nil || lineReader == nil {
+				continue
+			}
+
+			var lineEntry dwarf.LineEntry
+			for {
+				if err := lineReader.Next(&lineEntry); err == io.EOF {
+					break
+				} else if err != nil {
+					return image{}, err
+				}
+
+				row := lineRow{address: lineEntry.Address, line: lineEntry.Line, endSequence: lineEntry.EndSequence}
+				if lineEntry.File != nil {
+					row.file = lineEntry.File.Name
+				}
+				img.lines = append(img.lines, row)
+			}
+		case dwarf.TagSubprogram:
+			name, _ := entry.Val(dwarf.AttrName).(string)
+			if linkageName, ok := entry.Val(dwarf.AttrLinkageName).(string); ok && name == "" {
+				name = linkageName
+			}
+			if name == "" {
+				continue
+			}
+
+			ranges, err := data.Ranges(entry)
+			if err != nil {
+				continue
+			}
+			for _, r := range ranges {
+				img.functions = append(img.functions, addressRange{low: r[0], high: r[1], name: name})
+			}
+		}
+	}
+
+	sort.Slice(img.functions, func(i, j int) bool { return img.functions[i].low < img.functions[j].low })
+	sort.SliceStable(img.lines, func(i, j int) bool { return img.lines[i].address < img.lines[j].address })
+
+	return img, nil
+}
+
+// Archs ...
+func (symbolicator *Symbolicator) Archs() []string {
+	archs := []string{}
+	for arch := range symbolicator.images {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+	return archs
+}
+
+// Lookup returns the source location of the given offset from the executable's load address.
+func (symbolicator *Symbolicator) Lookup(arch string, offset uint64) (LocationModel, bool) {
+	img, ok := symbolicator.images[arch]
+	if !ok {
+		return LocationModel{}, false
+	}
+
+	address := img.textVMAddr + offset
+	location := LocationModel{}
+
+	i := sort.Search(len(img.functions), func(i int) bool { return img.functions[i].low > address }) - 1
+	if i >= 0 && address < img.functions[i].high {
+		location.Function = img.functions[i].name
+	}
+
+	if location.Function == "" {
+		i := sort.Search(len(img.symbols), func(i int) bool { return img.symbols[i].low > address }) - 1
+		if i >= 0 {
+			location.Function = fmt.Sprintf("%s + %d", img.symbols[i].name, address-img.symbols[i].low)
+		}
+	}
+
+	i = sort.Search(len(img.lines), func(i int) bool { return img.lines[i].address > address }) - 1
+	if i >= 0 && !img.lines[i].endSequence {
+		location.File = img.lines[i].file
+		location.Line = img.lines[i].line
+	}
+
+	if location.Function == "" && location.Line == 0 {
+		return LocationModel{}, false
+	}
+	if location.Function == "" {
+		location.Function = fmt.Sprintf("0x%x", address)
+	}
+	return location, true
+}
+
+// Symbolicate rewrites the executable's stack frames and image + offset pairs of the text to source locations.
+func (symbolicator *Symbolicator) Symbolicate(text string) string {
+	return symbolicator.symbolicate(text, func(s string) string { return s })
+}
+
+// SymbolicateXML is like Symbolicate, but escapes the inserted locations for XML content.
+func (symbolicator *Symbolicator) SymbolicateXML(text string) string {
+	return symbolicator.symbolicate(text, func(s string) string {
+		var buffer bytes.Buffer
+		if err := xml.EscapeText(&buffer, []byte(s)); err != nil {
+			return s
+		}
+		return buffer.String()
+	})
+}
+
+func (symbolicator *Symbolicator) arch(text string) string {
+	if matches := codeTypePattern.FindStringSubmatch(text); len(matches) == 2 {
+		arch := "armv7"
+		if strings.Contains(strings.ToUpper(matches[1]), "64") {
+			arch = "arm64"
+		}
+		if _, ok := symbolicator.images[arch]; ok {
+			return arch
+		}
+	}
+
+	if _, ok := symbolicator.images["arm64"]; ok {
+		return "arm64"
+	}
+	if archs := symbolicator.Archs(); len(archs) > 0 {
+		return archs[0]
+	}
+	return ""
+}
+
+func (symbolicator *Symbolicator) symbolicate(text string, escape func(string) string) string {
+	arch := symbolicator.arch(text)
+
+	lookup := func(offsetStr string) (string, bool) {
+		offset, err := strconv.ParseUint(offsetStr, 10, 64)
+		if err != nil {
+			return "", false
+		}
+		location, ok := symbolicator.Lookup(arch, offset)
+		if !ok {
+			return "", false
+		}
+		return escape(location.String()), true
+	}
+
+	text = framePattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := framePattern.FindStringSubmatch(match)
+		if groups[2] != symbolicator.BinaryName {
+			return match
+		}
+		location, ok := lookup(groups[5])
+		if !ok {
+			return match
+		}
+		return groups[1] + groups[2] + groups[3] + location
+	})
+
+	return imageOffsetPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := imageOffsetPattern.FindStringSubmatch(match)
+		if groups[1] != symbolicator.BinaryName {
+			return match
+		}
+		location, ok := lookup(groups[2])
+		if !ok {
+			return match
+		}
+		return groups[1] + " " + location
+	})
+}
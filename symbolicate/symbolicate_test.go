@@ -0,0 +1,209 @@
+package symbolicate
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fixtureDSYMPth is the dSYM of an arm64 Sample.iOS executable, its __TEXT segment starts at 0x100000000.
+// The DWARF data describes ViewController.c with the sort_values (0x1000-0x10c6), crash (0x10c6-0x1102)
+// and main (0x1102-0x112d) functions, the symbol table also lists xamarin_main (0x1180) without DWARF data.
+var fixtureDSYMPth = filepath.Join("testdata", "Sample.iOS.app.dSYM")
+
+// newTestSymbolicator returns a symbolicator of the Sample.iOS executable,
+// loaded at 0x100000000 for both architectures.
+func newTestSymbolicator() *Symbolicator {
+	return &Symbolicator{
+		BinaryName: "Sample.iOS",
+		images: map[string]image{
+			"arm64": {
+				textVMAddr: 0x100000000,
+				functions: []addressRange{
+					{low: 0x100010000, high: 0x100010100, name: "SampleiOS.ViewController.Crash"},
+					{low: 0x100010100, high: 0x100010200, name: "SampleiOS.Sorter<T>.Sort"},
+				},
+				symbols: []addressRange{
+					{low: 0x100010000, name: "SampleiOS_ViewController_Crash"},
+					{low: 0x100020000, name: "main"},
+				},
+				lines: []lineRow{
+					{address: 0x100010000, file: "/src/Sample.iOS/ViewController.cs", line: 20},
+					{address: 0x100010040, file: "/src/Sample.iOS/ViewController.cs", line: 25},
+					{address: 0x100010100, file: "/src/Sample.iOS/Sorter.cs", line: 7},
+					{address: 0x100010200, endSequence: true},
+				},
+			},
+			"armv7": {
+				textVMAddr: 0x100000000,
+				functions: []addressRange{
+					{low: 0x100010000, high: 0x100010100, name: "SampleiOS.ViewController.Crash(armv7)"},
+				},
+			},
+		},
+	}
+}
+
+func readFixture(t *testing.T, name string) string {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %s", err)
+	}
+	return string(content)
+}
+
+func TestLookup(t *testing.T) {
+	symbolicator := newTestSymbolicator()
+
+	tests := []struct {
+		name   string
+		arch   string
+		offset uint64
+		want   string
+		wantOK bool
+	}{
+		{"function start", "arm64", 0x10000, "SampleiOS.ViewController.Crash (ViewController.cs:20)", true},
+		{"function line", "arm64", 0x10044, "SampleiOS.ViewController.Crash (ViewController.cs:25)", true},
+		{"next function", "arm64", 0x10100, "SampleiOS.Sorter<T>.Sort (Sorter.cs:7)", true},
+		{"symbol fallback", "arm64", 0x20010, "main + 16", true},
+		{"before the first function", "arm64", 0x100, "", false},
+		{"function without lines", "armv7", 0x10010, "SampleiOS.ViewController.Crash(armv7)", true},
+		{"unknown arch", "x86_64", 0x10000, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			location, ok := symbolicator.Lookup(test.arch, test.offset)
+			if ok != test.wantOK {
+				t.Fatalf("ok: got %t, want %t", ok, test.wantOK)
+			}
+			if got := location.String(); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSymbolicate(t *testing.T) {
+	symbolicator := newTestSymbolicator()
+
+	want := readFixture(t, "crash.symbolicated.txt")
+	if got := symbolicator.Symbolicate(readFixture(t, "crash.txt")); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSymbolicateArch(t *testing.T) {
+	symbolicator := newTestSymbolicator()
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"arm64 code type", "Code Type: ARM-64 (Native)\nSample.iOS + 65536", "Code Type: ARM-64 (Native)\nSample.iOS SampleiOS.ViewController.Crash (ViewController.cs:20)"},
+		{"armv7 code type", "Code Type: ARM (Native)\nSample.iOS + 65536", "Code Type: ARM (Native)\nSample.iOS SampleiOS.ViewController.Crash(armv7)"},
+		{"no code type", "Sample.iOS + 65536", "Sample.iOS SampleiOS.ViewController.Crash (ViewController.cs:20)"},
+		{"other image", "Other.iOS + 65536", "Other.iOS + 65536"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := symbolicator.Symbolicate(test.text); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSymbolicateXML(t *testing.T) {
+	symbolicator := newTestSymbolicator()
+
+	text := "<message>Sample.iOS + 65792</message>"
+	want := "<message>Sample.iOS SampleiOS.Sorter&lt;T&gt;.Sort (Sorter.cs:7)</message>"
+	if got := symbolicator.SymbolicateXML(text); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestNew(t *testing.T) {
+	symbolicator, err := New(fixtureDSYMPth)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if symbolicator.BinaryName != "Sample.iOS" {
+		t.Errorf("binary name: got %s, want Sample.iOS", symbolicator.BinaryName)
+	}
+	if want := []string{"arm64"}; !reflect.DeepEqual(symbolicator.Archs(), want) {
+		t.Errorf("archs: got %v, want %v", symbolicator.Archs(), want)
+	}
+
+	tests := []struct {
+		name   string
+		offset uint64
+		want   string
+		wantOK bool
+	}{
+		{"function start", 0x1000, "sort_values (ViewController.c:2)", true},
+		{"loop body", 0x1094, "sort_values (ViewController.c:8)", true},
+		{"call", 0x10d2, "crash (ViewController.c:17)", true},
+		{"last function", 0x1120, "main (ViewController.c:24)", true},
+		{"symbol without DWARF data", 0x1190, "xamarin_main + 16", true},
+		{"before the text section", 0x100, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			location, ok := symbolicator.Lookup("arm64", test.offset)
+			if ok != test.wantOK {
+				t.Fatalf("ok: got %t, want %t", ok, test.wantOK)
+			}
+			if got := location.String(); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewSymbolicate(t *testing.T) {
+	symbolicator, err := New(fixtureDSYMPth)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	text := "1   Sample.iOS                    \t0x00000001000010d2 0x100000000 + 4306\nLast Exception Backtrace: Sample.iOS + 4244"
+	want := "1   Sample.iOS                    \t0x00000001000010d2 crash (ViewController.c:17)\nLast Exception Backtrace: Sample.iOS sort_values (ViewController.c:8)"
+	if got := symbolicator.Symbolicate(text); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestNewMissingDSYM(t *testing.T) {
+	if _, err := New(filepath.Join("testdata", "Missing.app.dSYM")); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestCrashLogAttachments(t *testing.T) {
+	pths, err := CrashLogAttachments(readFixture(t, "TestResult.attachments.xml"), "testdata")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{filepath.Join("testdata", "crash.txt"), "/results/Refund/Sample.iOS.crash"}
+	if !reflect.DeepEqual(pths, want) {
+		t.Errorf("got %v, want %v", pths, want)
+	}
+}
+
+func TestCrashLogAttachmentsWithoutAttachments(t *testing.T) {
+	pths, err := CrashLogAttachments("<test-run><test-case name=\"Pay\" result=\"Passed\" /></test-run>", "testdata")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(pths) != 0 {
+		t.Errorf("got %v, want none", pths)
+	}
+}
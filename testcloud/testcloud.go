@@ -3,12 +3,18 @@ package testcloud
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
 
 	"github.com/bitrise-io/go-utils/command"
 	"github.com/bitrise-io/go-utils/pathutil"
 	"github.com/bitrise-tools/go-xamarin/constants"
 )
 
+const redactedTestParamValue = "[REDACTED]"
+
 // Parallelization ...
 type Parallelization string
 
@@ -33,7 +39,9 @@ func ParseParallelization(parallelization string) (Parallelization, error) {
 	}
 }
 
-// Model ...
+// Model describes a test-cloud.exe submit command.
+// It is based on the testcloud tool of go-xamarin, extended with test parameters, locale
+// and the capturing of the whole command output.
 type Model struct {
 	testCloudExePth string
 
@@ -49,6 +57,8 @@ type Model struct {
 	series          string
 	nunitXMLPth     string
 	parallelization Parallelization
+	testParams      map[string]string
+	locale          string
 
 	signOptions   []string
 	customOptions []string
@@ -130,6 +140,19 @@ func (testCloud *Model) SetParallelization(parallelization Parallelization) *Mod
 	return testCloud
 }
 
+// SetTestParams sets the key:value pairs passed to the tests with --test-params,
+// the values are redacted in the printable command.
+func (testCloud *Model) SetTestParams(testParams map[string]string) *Model {
+	testCloud.testParams = testParams
+	return testCloud
+}
+
+// SetLocale ...
+func (testCloud *Model) SetLocale(locale string) *Model {
+	testCloud.locale = locale
+	return testCloud
+}
+
 // SetSignOptions ...
 func (testCloud *Model) SetSignOptions(options ...string) *Model {
 	testCloud.signOptions = options
@@ -142,7 +165,7 @@ func (testCloud *Model) SetCustomOptions(options ...string) *Model {
 	return testCloud
 }
 
-func (testCloud *Model) submitCommandSlice() []string {
+func (testCloud *Model) submitCommandSlice(redactTestParams bool) []string {
 	cmdSlice := []string{constants.MonoPath}
 	cmdSlice = append(cmdSlice, testCloud.testCloudExePth)
 	cmdSlice = append(cmdSlice, "submit")
@@ -184,14 +207,51 @@ func (testCloud *Model) submitCommandSlice() []string {
 		cmdSlice = append(cmdSlice, "--fixture-chunk")
 	}
 
-	cmdSlice = append(cmdSlice, testCloud.customOptions...)
+	if testCloud.locale != "" {
+		cmdSlice = append(cmdSlice, "--locale", testCloud.locale)
+	}
+
+	testParamKeys := []string{}
+	for key := range testCloud.testParams {
+		testParamKeys = append(testParamKeys, key)
+	}
+	sort.Strings(testParamKeys)
+
+	for _, key := range testParamKeys {
+		value := testCloud.testParams[key]
+		if redactTestParams && value != "" {
+			value = redactedTestParamValue
+		}
+		cmdSlice = append(cmdSlice, "--test-params", key+":"+value)
+	}
+
+	for i, option := range testCloud.customOptions {
+		if redactTestParams {
+			// the step rejects --test-params in the custom options, still never print their values
+			switch {
+			case i > 0 && testCloud.customOptions[i-1] == "--test-params":
+				option = redactedTestParam(option)
+			case strings.HasPrefix(option, "--test-params=") || strings.HasPrefix(option, "--test-params:"):
+				option = option[:len("--test-params=")] + redactedTestParam(option[len("--test-params="):])
+			}
+		}
+		cmdSlice = append(cmdSlice, option)
+	}
 
 	return cmdSlice
 }
 
-// PrintableCommand ...
+// redactedTestParam keeps the key of a key:value test parameter.
+func redactedTestParam(param string) string {
+	if split := strings.SplitN(param, ":", 2); len(split) == 2 && split[1] != "" {
+		return split[0] + ":" + redactedTestParamValue
+	}
+	return redactedTestParamValue
+}
+
+// PrintableCommand returns the submit command, with redacted test parameter values.
 func (testCloud Model) PrintableCommand() string {
-	cmdSlice := testCloud.submitCommandSlice()
+	cmdSlice := testCloud.submitCommandSlice(true)
 
 	return command.PrintableCommandArgs(true, cmdSlice)
 }
@@ -201,7 +261,7 @@ type CaptureLineCallback func(line string)
 
 // Submit ...
 func (testCloud Model) Submit(callback CaptureLineCallback) error {
-	cmdSlice := testCloud.submitCommandSlice()
+	cmdSlice := testCloud.submitCommandSlice(false)
 
 	command, err := command.NewFromSlice(cmdSlice)
 	if err != nil {
@@ -210,13 +270,15 @@ func (testCloud Model) Submit(callback CaptureLineCallback) error {
 
 	cmd := *command.GetCmd()
 
-	// Redirect output
+	// Redirect output, stderr is captured too, so failures printed there reach the callback
 	stdoutReader, err := cmd.StdoutPipe()
 	if err != nil {
 		return err
 	}
+	cmd.Stderr = cmd.Stdout
 
 	scanner := bufio.NewScanner(stdoutReader)
+	scanDone := make(chan error, 1)
 	go func() {
 		for scanner.Scan() {
 			line := scanner.Text()
@@ -224,14 +286,22 @@ func (testCloud Model) Submit(callback CaptureLineCallback) error {
 				callback(line)
 			}
 		}
+		// keep draining on scan error, so the command does not block on a full pipe
+		if _, err := io.Copy(ioutil.Discard, stdoutReader); err != nil && scanner.Err() == nil {
+			scanDone <- err
+			return
+		}
+		scanDone <- scanner.Err()
 	}()
-	if err := scanner.Err(); err != nil {
-		return err
-	}
 
 	if err := cmd.Start(); err != nil {
 		return err
 	}
 
-	return cmd.Wait()
+	// Wait closes the pipe, every line has to be read before
+	scanErr := <-scanDone
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+	return scanErr
 }
@@ -0,0 +1,61 @@
+package testcloud
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-tools/go-xamarin/constants"
+)
+
+func TestSubmitCommandSlice(t *testing.T) {
+	testCloud := Model{testCloudExePth: "/packages/Xamarin.UITest.2.2.4/tools/test-cloud.exe"}
+	testCloud.SetIPAPth("Sample.ipa").
+		SetAPIKey("api-key").
+		SetUser("user@example.com").
+		SetAssemblyDir("bin/Release").
+		SetDevices("devices").
+		SetSeries("master").
+		SetLocale("de_DE").
+		SetTestParams(map[string]string{"token": "secret", "endpoint": "staging", "empty": ""}).
+		SetCustomOptions("--category", "Smoke")
+
+	head := []string{constants.MonoPath, "/packages/Xamarin.UITest.2.2.4/tools/test-cloud.exe", "submit", "Sample.ipa", "api-key",
+		"--user", "user@example.com", "--assembly-dir", "bin/Release", "--devices", "devices", "--series", "master", "--locale", "de_DE"}
+
+	tests := []struct {
+		name   string
+		redact bool
+		want   []string
+	}{
+		{
+			name: "submitted",
+			want: append(append([]string{}, head...),
+				"--test-params", "empty:", "--test-params", "endpoint:staging", "--test-params", "token:secret", "--category", "Smoke"),
+		},
+		{
+			name:   "printed",
+			redact: true,
+			want: append(append([]string{}, head...),
+				"--test-params", "empty:", "--test-params", "endpoint:[REDACTED]", "--test-params", "token:[REDACTED]", "--category", "Smoke"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := testCloud.submitCommandSlice(test.redact); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got:\n%v\nwant:\n%v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSubmitCommandSliceRedactsCustomTestParams(t *testing.T) {
+	testCloud := Model{}
+	testCloud.SetCustomOptions("--test-params", "token:secret", "--test-params=endpoint:staging", "--test-params:flag", "--category", "token:secret")
+
+	want := []string{"--test-params", "token:[REDACTED]", "--test-params=endpoint:[REDACTED]", "--test-params:[REDACTED]", "--category", "token:secret"}
+	got := testCloud.submitCommandSlice(true)
+	if got = got[len(got)-len(want):]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
@@ -0,0 +1,266 @@
+package testfilter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/assembly"
+)
+
+// Kind ...
+type Kind string
+
+const (
+	// KindCategory ...
+	KindCategory Kind = "--category"
+	// KindFixture ...
+	KindFixture Kind = "--fixture"
+)
+
+// Model ...
+type Model struct {
+	Kind  Kind
+	Value string
+}
+
+// ResultModel ...
+type ResultModel struct {
+	Filter      Model
+	TestCount   int
+	Suggestions []string
+}
+
+// Split splits a comma separated input into filters of the given kind.
+func Split(kind Kind, value string) []Model {
+	filters := []Model{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			filters = append(filters, Model{Kind: kind, Value: item})
+		}
+	}
+	return filters
+}
+
+// Parse collects the --category and --fixture filters of test-cloud.exe options,
+// given as separate arguments or in the --kind=value and --kind:value forms.
+func Parse(options []string) []Model {
+	filters := []Model{}
+	for i := 0; i < len(options); i++ {
+		for _, kind := range []Kind{KindCategory, KindFixture} {
+			if options[i] == string(kind) {
+				if i+1 < len(options) {
+					filters = append(filters, Model{Kind: kind, Value: options[i+1]})
+					i++
+				}
+				break
+			}
+
+			if value, ok := inlineValue(options[i], kind); ok {
+				filters = append(filters, Model{Kind: kind, Value: value})
+				break
+			}
+		}
+	}
+	return filters
+}
+
+// inlineValue returns the value of the --kind=value or --kind:value option.
+func inlineValue(option string, kind Kind) (string, bool) {
+	for _, separator := range []string{"=", ":"} {
+		if prefix := string(kind) + separator; strings.HasPrefix(option, prefix) {
+			return strings.TrimPrefix(option, prefix), true
+		}
+	}
+	return "", false
+}
+
+// Options converts the filters to test-cloud.exe options.
+func Options(filters []Model) []string {
+	options := []string{}
+	for _, filter := range filters {
+		options = append(options, string(filter.Kind), filter.Value)
+	}
+	return options
+}
+
+// Validate counts the tests each filter selects and collects suggestions for filters selecting none.
+func Validate(filters []Model, inventories []assembly.InventoryModel) []ResultModel {
+	results := []ResultModel{}
+	for _, filter := range filters {
+		result := ResultModel{Filter: filter}
+
+		candidates := []string{}
+		for _, inventory := range inventories {
+			for _, fixture := range inventory.Fixtures {
+				switch filter.Kind {
+				case KindCategory:
+					result.TestCount += categoryTestCount(fixture, filter.Value)
+					candidates = append(candidates, fixture.Categories...)
+					for _, test := range fixture.Tests {
+						candidates = append(candidates, test.Categories...)
+					}
+				case KindFixture:
+					if fixture.FullName == filter.Value {
+						result.TestCount += fixture.TestCount()
+					}
+					candidates = append(candidates, fixture.FullName)
+				}
+			}
+		}
+
+		if result.TestCount == 0 {
+			result.Suggestions = Suggestions(filter.Value, candidates)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func categoryTestCount(fixture assembly.FixtureModel, category string) int {
+	if fixture.Ignored {
+		return 0
+	}
+
+	fixtureMatches := contains(fixture.Categories, category)
+
+	count := 0
+	for _, test := range fixture.Tests {
+		if !test.Ignored && (fixtureMatches || contains(test.Categories, category)) {
+			count += test.Cases
+		}
+	}
+	return count * fixture.Instances
+}
+
+// Selects reports whether any of the filters selects the test of the fixture,
+// without filters every test is selected.
+func Selects(filters []Model, fixture assembly.FixtureModel, test assembly.TestModel) bool {
+	if fixture.Ignored || test.Ignored {
+		return false
+	}
+	if len(filters) == 0 {
+		return true
+	}
+
+	for _, filter := range filters {
+		switch filter.Kind {
+		case KindCategory:
+			if contains(fixture.Categories, filter.Value) || contains(test.Categories, filter.Value) {
+				return true
+			}
+		case KindFixture:
+			if fixture.FullName == filter.Value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Error returns an error describing the filters which select no tests, or nil.
+func Error(results []ResultModel) error {
+	messages := []string{}
+	for _, result := range results {
+		if result.TestCount > 0 {
+			continue
+		}
+
+		message := fmt.Sprintf("%s %s matches no tests", result.Filter.Kind, result.Filter.Value)
+		if len(result.Suggestions) > 0 {
+			message += fmt.Sprintf(", did you mean: %s?", strings.Join(result.Suggestions, ", "))
+		}
+		messages = append(messages, message)
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(messages, "\n"))
+}
+
+// Suggestions returns the candidates similar to the value, the most similar first.
+func Suggestions(value string, candidates []string) []string {
+	type suggestion struct {
+		value    string
+		distance int
+	}
+
+	seen := map[string]bool{}
+	suggestions := []suggestion{}
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		lowerValue, lowerCandidate := strings.ToLower(value), strings.ToLower(candidate)
+		distance := levenshtein(lowerValue, lowerCandidate)
+
+		maxDistance := len(value) / 3
+		if maxDistance < 2 {
+			maxDistance = 2
+		}
+
+		if distance <= maxDistance || strings.HasSuffix(lowerCandidate, "."+lowerValue) {
+			suggestions = append(suggestions, suggestion{candidate, distance})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+		return suggestions[i].value < suggestions[j].value
+	})
+
+	values := []string{}
+	for i, s := range suggestions {
+		if i == 3 {
+			break
+		}
+		values = append(values, s.value)
+	}
+	return values
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
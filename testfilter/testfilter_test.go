@@ -0,0 +1,232 @@
+package testfilter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bitrise-steplib/steps-xamarin-test-cloud-for-ios/assembly"
+)
+
+var testInventories = []assembly.InventoryModel{
+	{
+		Assembly: "Sample.UITests.dll",
+		Fixtures: []assembly.FixtureModel{
+			{
+				FullName:   "Sample.UITests.LoginTests",
+				Categories: []string{"Smoke"},
+				Instances:  2,
+				Tests: []assembly.TestModel{
+					{Name: "Login", Cases: 1},
+					{Name: "LoginFails", Categories: []string{"Negative"}, Cases: 3},
+					{Name: "LoginTimeout", Categories: []string{"Negative"}, Cases: 1, Ignored: true},
+				},
+			},
+			{
+				FullName:  "Sample.UITests.CheckoutTests",
+				Instances: 1,
+				Tests: []assembly.TestModel{
+					{Name: "Pay", Categories: []string{"Payments"}, Cases: 1},
+					{Name: "Refund", Categories: []string{"Payments", "Slow"}, Cases: 1},
+				},
+			},
+		},
+	},
+	{
+		Assembly: "Admin.UITests.dll",
+		Fixtures: []assembly.FixtureModel{
+			{
+				FullName:   "Admin.UITests.LegacyTests",
+				Categories: []string{"Legacy"},
+				Instances:  1,
+				Ignored:    true,
+				Tests:      []assembly.TestModel{{Name: "Export", Cases: 1}},
+			},
+		},
+	},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []string
+		want    []Model
+	}{
+		{
+			name:    "separate value",
+			options: []string{"--category", "Smoke", "--fixture", "Sample.UITests.LoginTests"},
+			want:    []Model{{KindCategory, "Smoke"}, {KindFixture, "Sample.UITests.LoginTests"}},
+		},
+		{
+			name:    "equal sign",
+			options: []string{"--category=Smoke", "--fixture=Sample.UITests.LoginTests"},
+			want:    []Model{{KindCategory, "Smoke"}, {KindFixture, "Sample.UITests.LoginTests"}},
+		},
+		{
+			name:    "colon",
+			options: []string{"--category:Smoke", "--fixture:Sample.UITests.LoginTests"},
+			want:    []Model{{KindCategory, "Smoke"}, {KindFixture, "Sample.UITests.LoginTests"}},
+		},
+		{
+			name:    "other options",
+			options: []string{"--series", "master", "--categoryless", "--category"},
+			want:    []Model{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Parse(test.options); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name            string
+		filter          Model
+		wantTestCount   int
+		wantSuggestions []string
+	}{
+		{"fixture category", Model{KindCategory, "Smoke"}, 8, nil},
+		{"test category", Model{KindCategory, "Negative"}, 6, nil},
+		{"test category of multiple tests", Model{KindCategory, "Payments"}, 2, nil},
+		{"category of an ignored fixture", Model{KindCategory, "Legacy"}, 0, []string{"Legacy"}},
+		{"misspelled category", Model{KindCategory, "Smoek"}, 0, []string{"Smoke"}},
+		{"category in other case", Model{KindCategory, "payments"}, 0, []string{"Payments"}},
+		{"unknown category", Model{KindCategory, "Regression"}, 0, []string{}},
+		{"fixture", Model{KindFixture, "Sample.UITests.LoginTests"}, 8, nil},
+		{"fixture without namespace", Model{KindFixture, "LoginTests"}, 0, []string{"Sample.UITests.LoginTests"}},
+		{"misspelled fixture", Model{KindFixture, "Sample.UITests.LoginTest"}, 0, []string{"Sample.UITests.LoginTests"}},
+		{"ignored fixture", Model{KindFixture, "Admin.UITests.LegacyTests"}, 0, []string{"Admin.UITests.LegacyTests"}},
+		{"unknown fixture", Model{KindFixture, "SearchTests"}, 0, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := Validate([]Model{test.filter}, testInventories)
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want 1", len(results))
+			}
+
+			result := results[0]
+			if result.Filter != test.filter {
+				t.Errorf("filter: got %+v, want %+v", result.Filter, test.filter)
+			}
+			if result.TestCount != test.wantTestCount {
+				t.Errorf("test count: got %d, want %d", result.TestCount, test.wantTestCount)
+			}
+			if !reflect.DeepEqual(result.Suggestions, test.wantSuggestions) {
+				t.Errorf("suggestions: got %#v, want %#v", result.Suggestions, test.wantSuggestions)
+			}
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	results := Validate([]Model{
+		{KindCategory, "Smoke"},
+		{KindCategory, "Smoek"},
+		{KindFixture, "SearchTests"},
+	}, testInventories)
+
+	want := "--category Smoek matches no tests, did you mean: Smoke?\n" +
+		"--fixture SearchTests matches no tests"
+	if err := Error(results); err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+
+	if err := Error(results[:1]); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      string
+		candidates []string
+		want       []string
+	}{
+		{
+			name:       "ranked by distance, then by name",
+			value:      "Smoke",
+			candidates: []string{"Spoke", "Joke", "Smoky", "Smokes", "Smoke2"},
+			want:       []string{"Smoke2", "Smokes", "Smoky"},
+		},
+		{
+			name:       "case insensitive match first",
+			value:      "Smoke",
+			candidates: []string{"Smoky", "SMOKE"},
+			want:       []string{"SMOKE", "Smoky"},
+		},
+		{
+			name:       "duplicates",
+			value:      "Smoke",
+			candidates: []string{"Smoky", "Smoky", "Smoky"},
+			want:       []string{"Smoky"},
+		},
+		{
+			name:       "short values allow a distance of 2",
+			value:      "Pay",
+			candidates: []string{"Pa", "P", "Payment", "Day"},
+			want:       []string{"Day", "Pa", "P"},
+		},
+		{
+			name:       "long values allow a third of their length",
+			value:      "Payments",
+			candidates: []string{"Payment", "Paymnt", "Pay", "Pavements"},
+			want:       []string{"Payment", "Pavements", "Paymnt"},
+		},
+		{
+			name:       "beyond the distance cutoff",
+			value:      "Payments",
+			candidates: []string{"Pay", "Refunds", "Slow"},
+			want:       []string{},
+		},
+		{
+			name:       "namespace suffix beyond the distance cutoff",
+			value:      "LoginTests",
+			candidates: []string{"Sample.UITests.LoginTests", "Sample.UITests.LogoutTests"},
+			want:       []string{"Sample.UITests.LoginTests"},
+		},
+		{
+			name:       "no candidates",
+			value:      "Smoke",
+			candidates: nil,
+			want:       []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Suggestions(test.value, test.candidates); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"", "", 0},
+		{"smoke", "smoke", 0},
+		{"smoke", "", 5},
+		{"", "smoke", 5},
+		{"smoke", "smoek", 2},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"café", "cafe", 1},
+	}
+
+	for _, test := range tests {
+		if got := levenshtein(test.a, test.b); got != test.want {
+			t.Errorf("levenshtein(%q, %q): got %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}